@@ -3,37 +3,164 @@ package main
 import (
 	"fmt"
 	"go-example/internal/config"
-	"go-example/internal/entities"
+	"go-example/internal/database"
 	"go-example/internal/log"
+	"go-example/internal/migrations"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var (
 	migrateCMD = &cobra.Command{
 		Use:   "migrate",
-		Short: "migrate db schema and seed data",
-		Run:   migrateCMDRunner,
+		Short: "migrate db schema, apply every pending migration without subcommand",
+		Args:  cobra.NoArgs,
+		RunE:  migrateUp,
 	}
+	migrateUpCMD = &cobra.Command{
+		Use:   "up",
+		Short: "apply every pending migration",
+		Args:  cobra.NoArgs,
+		RunE:  migrateUp,
+	}
+	migrateDownCMD = &cobra.Command{
+		Use:   "down [N]",
+		Short: "revert the N last applied migrations, default 1",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  migrateDown,
+	}
+	migrateToCMD = &cobra.Command{
+		Use:   "to VERSION",
+		Short: "apply or revert migrations until VERSION, 0 revert all",
+		Args:  cobra.ExactArgs(1),
+		RunE:  migrateTo,
+	}
+	migrateStatusCMD = &cobra.Command{
+		Use:   "status",
+		Short: "list migrations and when they were applied",
+		Args:  cobra.NoArgs,
+		RunE:  migrateStatus,
+	}
+	migrateCreateCMD = &cobra.Command{
+		Use:   "create NAME",
+		Short: "create empty up and down scripts of a new migration",
+		Args:  cobra.ExactArgs(1),
+		RunE:  migrateCreate,
+	}
+	migrationsDir string
 )
 
 func init() {
 	rootCmd.AddCommand(migrateCMD)
+	migrateCMD.AddCommand(migrateUpCMD, migrateDownCMD, migrateToCMD, migrateStatusCMD, migrateCreateCMD)
+	migrateCreateCMD.Flags().StringVar(&migrationsDir, "dir", "internal/migrations/sql", "directory of the migration scripts")
+	for _, cmd := range []*cobra.Command{migrateCMD, migrateUpCMD, migrateDownCMD, migrateToCMD, migrateStatusCMD, migrateCreateCMD} {
+		// failures are reported by the error, not by the usage
+		cmd.SilenceUsage = true
+	}
+}
+
+func migrateUp(cmd *cobra.Command, args []string) error {
+	return withMigrator(func(m *migrations.Migrator) error {
+		done, err := m.Up(cmd.Context())
+		logMigrations("Applied", done)
+		return err
+	})
 }
 
-func migrateCMDRunner(cmd *cobra.Command, agrs []string) {
-	log.Info("Start migrate")
-	db, err := gorm.Open(postgres.Open(config.Default.Database.URL))
+func migrateDown(cmd *cobra.Command, args []string) error {
+	n := 1
+	if len(args) == 1 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+			return fmt.Errorf("invalid number of migrations %q", args[0])
+		}
+	}
+	return withMigrator(func(m *migrations.Migrator) error {
+		done, err := m.Down(cmd.Context(), n)
+		logMigrations("Reverted", done)
+		return err
+	})
+}
+
+func migrateTo(cmd *cobra.Command, args []string) error {
+	version, err := strconv.ParseUint(args[0], 10, 64)
 	if err != nil {
-		log.Fatal(
-			fmt.Sprintf("Failed to connect database[%v]: %v", config.Parse().Database.URL, err))
+		return fmt.Errorf("invalid version %q", args[0])
 	}
-	defer func() {
-		if dbSql, err := db.DB(); err != nil {
-			dbSql.Close()
+	return withMigrator(func(m *migrations.Migrator) error {
+		done, err := m.To(cmd.Context(), version)
+		logMigrations("Migrated", done)
+		return err
+	})
+}
+
+func migrateStatus(cmd *cobra.Command, args []string) error {
+	return withMigrator(func(m *migrations.Migrator) error {
+		status, err := m.Status(cmd.Context())
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range status {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
 		}
-	}()
-	entities.AutoMigrate(db)
+		return w.Flush()
+	})
+}
+
+func migrateCreate(cmd *cobra.Command, args []string) error {
+	up, down, err := migrations.Create(migrationsDir, args[0])
+	if err != nil {
+		return err
+	}
+	log.Info("Created migration " + up + " and " + down)
+	return nil
+}
+
+// withMigrator run fn with a migrator of the embedded migrations on the
+// configured database
+func withMigrator(fn func(m *migrations.Migrator) error) error {
+	db, err := openMaintenance()
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	m, err := migrations.New(sqlDB, migrations.FS())
+	if err != nil {
+		return err
+	}
+	return fn(m)
+}
+
+// openMaintenance open the configured database without the statement timeout
+// of the request handlers, migrate waits for the lock held by other replicas
+// and both migrate and seed may run statements longer than it
+func openMaintenance() (*gorm.DB, error) {
+	cnf := config.Default.Database
+	cnf.Pool.StatementTimeout = 0
+	return database.Open(cnf)
+}
+
+func logMigrations(action string, done []migrations.Migration) {
+	for _, m := range done {
+		log.Info(action + " migration " + m.String())
+	}
+	if len(done) == 0 {
+		log.Info("No migration to run")
+	}
 }
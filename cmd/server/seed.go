@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"go-example/internal/database"
+	"go-example/internal/log"
+	"go-example/internal/seed"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	seedCMD = &cobra.Command{
+		Use:          "seed",
+		Short:        "seed users, roles and products from the fixtures of an environment",
+		Long:         `seed upsert the fixtures of <dir>/<env>/*.{yaml,yml,json} by username, role name and product code`,
+		Args:         cobra.NoArgs,
+		RunE:         seedCMDRunner,
+		SilenceUsage: true,
+	}
+	fixturesDir string
+	fixturesEnv string
+	seedReset   bool
+)
+
+func init() {
+	rootCmd.AddCommand(seedCMD)
+	seedCMD.Flags().StringVar(&fixturesDir, "dir", "config/fixtures", "directory of the fixture environments")
+	seedCMD.Flags().StringVarP(&fixturesEnv, "env", "e", "local", "environment of the fixtures")
+	seedCMD.Flags().BoolVar(&seedReset, "reset", false, "truncate users, roles and products before seeding")
+}
+
+func seedCMDRunner(cmd *cobra.Command, args []string) error {
+	dir := filepath.Join(fixturesDir, fixturesEnv)
+	fixtures, err := seed.Load(os.DirFS(dir))
+	if err != nil {
+		return fmt.Errorf("failed to load fixtures of %s: %w", dir, err)
+	}
+	db, err := openMaintenance()
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+	if seedReset {
+		log.Warn("Reset users, roles and products")
+	}
+	result, err := seed.Seed(db, fixtures, seedReset)
+	if err != nil {
+		return err
+	}
+	log.Info(fmt.Sprintf("Seeded %d roles, %d users and %d products from %s", result.Roles, result.Users, result.Products, dir))
+	return nil
+}
@@ -2,23 +2,38 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"go-example/docs"
+	"go-example/internal/admin"
+	v1 "go-example/internal/api/v1"
+	"go-example/internal/auth"
 	"go-example/internal/config"
+	"go-example/internal/database"
+	"go-example/internal/errors"
+	"go-example/internal/health"
 	"go-example/internal/log"
 	internalMetric "go-example/internal/metric"
+	"go-example/internal/route"
+	"go-example/internal/tlsconfig"
 	internalTrace "go-example/internal/trace"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
+	"time"
 
 	// "go-example/internal/log"
 	"strings"
 
+	"github.com/gin-gonic/gin"
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/chi/v5"
 	"github.com/spf13/cobra"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/global"
+	"gorm.io/gorm"
 )
 
 var (
@@ -27,7 +42,9 @@ var (
 		Use:   "start",
 		Short: "start server",
 		Long:  `start server, default port is 5000`,
-		Run:   startServer,
+		RunE:  startServer,
+		// a failed server exits non zero without printing the usage
+		SilenceUsage: true,
 	}
 	enablePprof bool
 )
@@ -37,7 +54,7 @@ func init() {
 	rootCmd.AddCommand(startCmd)
 	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "config file (default is $PWD/config/default.yaml)")
 	startCmd.PersistentFlags().Int("port", 5000, "Port to run Application server on")
-	startCmd.PersistentFlags().BoolVarP(&enablePprof, "pprof", "p", false, "enable pprof mode (default: false)")
+	startCmd.PersistentFlags().BoolVarP(&enablePprof, "pprof", "p", false, "enable pprof on the admin listener (default: false)")
 	config.Viper().BindPFlag("port", startCmd.PersistentFlags().Lookup("port"))
 }
 
@@ -59,15 +76,14 @@ func initConfig() {
 }
 
 func initLogger() {
-	log.ResetDefault(log.New(os.Stderr, config.Default.Otel.Log))
+	log.ResetDefault(log.New(os.Stderr, config.Default.Otel.Log.Config))
 }
 
-func startServer(cmd *cobra.Command, agrs []string) {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer cancel()
+func startServer(cmd *cobra.Command, agrs []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	observabilityCloser := initObservability(ctx)
-	defer observabilityCloser(ctx)
 
 	// tracer := otel.Tracer("test-tracer")
 	// Attributes represent additional key-value descriptors that can be bound
@@ -91,38 +107,202 @@ func startServer(cmd *cobra.Command, agrs []string) {
 	// }
 
 	log.Info("Start http-server")
-	// db, err := gorm.Open(postgres.Open(config.Default.Database.URL))
-	// if err != nil {
-	// 	log.Fatal(fmt.Sprint("Failed to connect database: ", err))
-	// }
-	// sqlDB, err := db.DB()
-	// if err != nil {
-	// 	log.Fatal("Can't connect database")
-	// }
-	// sqlDB.SetMaxOpenConns(int(config.Default.Database.Pool.Max))
-	// defer func() {
-	// 	sqlDB.Close()
-	// 	log.Info("Closed db connection")
-	// }()
-	// go entities.AutoMigrate(db)
+	db, err := database.Open(config.Default.Database)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	unregisterDBStats := initDBStats(db)
 	setupDoc()
 	r := chi.NewRouter()
 
 	r.Use(middleware.RequestID)
+	r.Use(internalTrace.Middleware())
+	if httpMetrics, err := internalMetric.Middleware(global.MeterProvider()); err != nil {
+		log.Error("failed to measure http requests: " + err.Error())
+	} else {
+		r.Use(httpMetrics)
+	}
 	r.Use(middleware.RequestLogger(log.Default()))
 	r.Use(middleware.RedirectSlashes)
 	r.Use(middleware.Recoverer)
 
-	if enablePprof {
-		r.Mount("/debug", middleware.Profiler())
-	}
-
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("Hello World!"))
 	})
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {})
 	r.Get("/doc/*", httpSwagger.WrapHandler)
-	http.ListenAndServe(fmt.Sprintf("%s:%d", config.Default.Server.Host, config.Default.Server.Port), r)
+	// prometheus scrape endpoint, served on the admin listener by default
+	var adminMetrics http.Handler
+	if metrics := internalMetric.Handler(); metrics != nil {
+		if config.Default.Otel.Metric.ServedOn() == internalMetric.ListenerPublic {
+			r.Handle("/metrics", metrics)
+		} else {
+			adminMetrics = metrics
+		}
+	}
+	tokens, err := auth.New(config.Default.Auth)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	r.Mount(docs.SwaggerInfo.BasePath, newAPIHandler(db, tokens, auth.NewPolicy(config.Default.Auth.Policy)))
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", config.Default.Server.Host, config.Default.Server.Port),
+		Handler: r,
+	}
+	if config.Default.Server.TLS.Enabled() {
+		if srv.TLSConfig, err = tlsconfig.New(config.Default.Server.TLS); err != nil {
+			log.Fatal(err.Error())
+		}
+	}
+	servers := []*http.Server{srv}
+
+	registry := initHealth(db)
+	if adminCnf := config.Default.Server.Admin; adminCnf.Enabled() {
+		log.Info("Start admin http-server")
+		servers = append(servers, &http.Server{
+			Addr: fmt.Sprintf("%s:%d", adminCnf.Host, adminCnf.Port),
+			Handler: admin.NewRouter(admin.Options{
+				Build:   admin.BuildInfo{Version: Version, GitCommit: GitCommit, BuildDate: BuildDate},
+				Health:  registry,
+				Config:  config.Masked,
+				Pprof:   enablePprof,
+				Metrics: adminMetrics,
+			}),
+		})
+	} else {
+		log.Warn("admin listener disabled, health probes are served on the public listener")
+		admin.MountHealth(r, registry)
+		if enablePprof {
+			log.Warn("pprof is only served on the admin listener, configure server.admin.port to enable it")
+		}
+		if adminMetrics != nil {
+			log.Warn("metrics are served on the admin listener, configure server.admin.port or otel.metric.listener: public to enable them")
+		}
+	}
+
+	serveErr := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func(srv *http.Server) {
+			if srv.TLSConfig != nil {
+				// certificates are served by srv.TLSConfig
+				serveErr <- srv.ListenAndServeTLS("", "")
+				return
+			}
+			serveErr <- srv.ListenAndServe()
+		}(srv)
+	}
+
+	var failed error
+	select {
+	case err := <-serveErr:
+		if err != http.ErrServerClosed {
+			log.Error("http-server stopped: " + err.Error())
+			failed = fmt.Errorf("http-server stopped: %w", err)
+		}
+	case <-ctx.Done():
+		log.Info("Received shutdown signal")
+	}
+	// restore default signal handling, a second signal terminates immediately
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.Default.Server.ShutdownTimeout)
+	defer cancel()
+
+	log.Info("Shutdown http-server")
+	for _, srv := range servers {
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Error("failed to drain http-server connections: " + err.Error())
+		}
+	}
+	unregisterDBStats()
+	if err := database.Close(db); err != nil {
+		log.Error("failed to close db connection: " + err.Error())
+	} else {
+		log.Info("Closed db connection")
+	}
+	observabilityCloser(shutdownCtx)
+	return failed
+}
+
+// initDBStats observe the connection pools of db and of its replicas, the
+// returned function stop the observation
+func initDBStats(db *gorm.DB) func() {
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	pools := map[string]*sql.DB{"primary": sqlDB}
+	if router, ok := db.ConnPool.(*database.Router); ok {
+		for name, replica := range router.Replicas() {
+			pools[name] = replica
+		}
+	}
+	unregisters := []func() error{}
+	for name, pool := range pools {
+		unregister, err := internalMetric.RegisterDBStats(global.MeterProvider(), pool, attribute.String("pool.name", name))
+		if err != nil {
+			log.Error("failed to observe db connection pool: " + err.Error())
+			continue
+		}
+		unregisters = append(unregisters, unregister)
+	}
+	return func() {
+		for _, unregister := range unregisters {
+			if err := unregister(); err != nil {
+				log.Error("failed to stop observing db connection pool: " + err.Error())
+			}
+		}
+	}
+}
+
+// initHealth register the health checks of the server components
+func initHealth(db *gorm.DB) *health.Registry {
+	registry := health.NewRegistry(config.Default.Health)
+	registry.Register("database", health.DatabaseCheck(db))
+	// reads fall back to the primary while replicas are down
+	if router, ok := db.ConnPool.(*database.Router); ok {
+		for name, replica := range router.Replicas() {
+			registry.Register("database-"+name, replica.PingContext, health.Optional())
+		}
+	}
+	// telemetry outage should not take the service out of rotation
+	if endpoint := config.Default.Otel.Trace.Endpoint; endpoint != "" {
+		registry.Register("otel-trace-exporter", health.DialCheck("tcp", endpoint), health.Optional())
+	}
+	if endpoint := config.Default.Otel.Metric.Endpoint; endpoint != "" {
+		registry.Register("otel-metric-exporter", health.DialCheck("tcp", endpoint), health.Optional())
+	}
+	if export := config.Default.Otel.Log.Export; export.Endpoint != "" && !strings.Contains(export.Endpoint, "://") {
+		registry.Register("otel-log-exporter", health.DialCheck("tcp", export.Endpoint), health.Optional())
+	}
+	if disk := config.Default.Health.Disk; disk.Path != "" {
+		registry.Register("disk", health.DiskSpaceCheck(disk), health.Optional())
+	}
+	return registry
+}
+
+// newAPIHandler serve the gin based api under the chi router, so the chi
+// middleware stack applies to api routes as well
+func newAPIHandler(db *gorm.DB, tokens *auth.Tokens, policy auth.Policy) http.Handler {
+	api := gin.New()
+	api.Use(route.GinPattern(), requestTimeout(config.Default.Server.RequestTimeout), errors.GinError(), database.GinReadYourWrites())
+	v1.RegisterRouterAPIV1(api.Group(docs.SwaggerInfo.BasePath), db, tokens, policy)
+	return api
+}
+
+// requestTimeout set the deadline of the request context, queries still
+// running when it expires are cancelled and the request fails with 504
+func requestTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
 }
 
 func setupDoc() {
@@ -132,12 +312,32 @@ func setupDoc() {
 	docs.SwaggerInfo.Version = Version
 	docs.SwaggerInfo.Host = fmt.Sprintf("%s:%d", config.Default.Server.Host, config.Default.Server.Port)
 	docs.SwaggerInfo.BasePath = "/api/v1"
-	docs.SwaggerInfo.Schemes = []string{"http", "https"}
+	docs.SwaggerInfo.Schemes = []string{"http"}
+	if config.Default.Server.TLS.Enabled() {
+		docs.SwaggerInfo.Schemes = []string{"https"}
+	}
 }
 
 func initObservability(ctx context.Context) (close func(context.Context)) {
 	closedFns := []func(context.Context){}
 
+	// log exporter initialization, closed last to ship the shutdown logs
+	log.Info("Start log exporter")
+	shutdownLogs, err := log.InitExporter(ctx, config.Default.Metadata.ServiceName, Version, config.Default.Otel.Log.Export)
+	if err != nil {
+		// logs are still written to stderr, the service runs without export
+		log.Warn("log export disabled: " + err.Error())
+	}
+
+	if shutdownLogs != nil {
+		closedFns = append(closedFns, func(ctx context.Context) {
+			if err := shutdownLogs(ctx); err != nil {
+				// the exporter is closed, the error is only written to stderr
+				log.Error("failed to shutdown log exporter: " + err.Error())
+			}
+		})
+	}
+
 	// tracer initialization
 	log.Info("Start trace provider")
 	shutdownTrace, err := internalTrace.InitTraceProvider(ctx, config.Default.Metadata.ServiceName, Version, config.Default.Otel.Trace)
@@ -145,13 +345,13 @@ func initObservability(ctx context.Context) (close func(context.Context)) {
 		if err != internalTrace.ErrUndefindedTraceProto {
 			log.Fatal(err.Error())
 		}
-		log.Info(err.Error())
+		log.Warn(err.Error())
 	}
 
 	if shutdownTrace != nil {
 		closedFns = append(closedFns, func(ctx context.Context) {
 			if err := shutdownTrace(ctx); err != nil {
-				log.Fatal("failed to shutdown TracerProvider: " + err.Error())
+				log.Error("failed to shutdown TracerProvider: " + err.Error())
 			}
 		})
 	}
@@ -163,19 +363,19 @@ func initObservability(ctx context.Context) (close func(context.Context)) {
 		if err != internalMetric.ErrUndefindedMetricProto {
 			log.Fatal(err.Error())
 		}
-		log.Info(err.Error())
+		log.Warn(err.Error())
 	}
 
 	if shutdownMeter != nil {
 		closedFns = append(closedFns, func(ctx context.Context) {
 			if err := shutdownMeter(ctx); err != nil {
-				log.Fatal("failed to shutdown MeterProvider: " + err.Error())
+				log.Error("failed to shutdown MeterProvider: " + err.Error())
 			}
 		})
 	}
 
 	return func(ctx context.Context) {
-		for i := len(closedFns) - 1; i > 0; i-- {
+		for i := len(closedFns) - 1; i >= 0; i-- {
 			closedFns[i](ctx)
 		}
 	}
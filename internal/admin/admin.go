@@ -0,0 +1,118 @@
+package admin
+
+import (
+	"encoding/json"
+	"go-example/internal/health"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/chi/v5"
+)
+
+// Config of the internal admin listener, disabled when Port is 0
+type Config struct {
+	Host string
+	Port uint
+}
+
+// Enabled report whether the admin listener is configured
+func (c Config) Enabled() bool {
+	return c.Port != 0
+}
+
+// BuildInfo of the running binary
+type BuildInfo struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"gitCommit"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+}
+
+// RuntimeInfo snapshot of the go runtime
+type RuntimeInfo struct {
+	Uptime       string `json:"uptime"`
+	Goroutines   int    `json:"goroutines"`
+	NumCPU       int    `json:"numCPU"`
+	GOMAXPROCS   int    `json:"gomaxprocs"`
+	HeapAlloc    uint64 `json:"heapAlloc"`
+	HeapInuse    uint64 `json:"heapInuse"`
+	HeapObjects  uint64 `json:"heapObjects"`
+	Sys          uint64 `json:"sys"`
+	NumGC        uint32 `json:"numGC"`
+	PauseTotalNs uint64 `json:"pauseTotalNs"`
+}
+
+// Options of the admin router
+type Options struct {
+	Build  BuildInfo
+	Health *health.Registry
+	// Config return the effective configuration, secrets must be masked
+	Config func() map[string]interface{}
+	Pprof  bool
+	// Metrics serve /metrics for scrapers, not mounted when nil
+	Metrics http.Handler
+}
+
+var startedAt = time.Now()
+
+// NewRouter create the router of the admin listener
+func NewRouter(opts Options) http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.Recoverer)
+
+	if opts.Pprof {
+		r.Mount("/debug", middleware.Profiler())
+	}
+	if opts.Health != nil {
+		MountHealth(r, opts.Health)
+	}
+	if opts.Metrics != nil {
+		r.Handle("/metrics", opts.Metrics)
+	}
+	r.Get("/buildinfo", func(w http.ResponseWriter, r *http.Request) {
+		build := opts.Build
+		build.GoVersion = runtime.Version()
+		build.OS = runtime.GOOS
+		build.Arch = runtime.GOARCH
+		writeJSON(w, build)
+	})
+	if opts.Config != nil {
+		r.Get("/config", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, opts.Config())
+		})
+	}
+	r.Get("/runtime", func(w http.ResponseWriter, r *http.Request) {
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		writeJSON(w, RuntimeInfo{
+			Uptime:       time.Since(startedAt).String(),
+			Goroutines:   runtime.NumGoroutine(),
+			NumCPU:       runtime.NumCPU(),
+			GOMAXPROCS:   runtime.GOMAXPROCS(0),
+			HeapAlloc:    mem.HeapAlloc,
+			HeapInuse:    mem.HeapInuse,
+			HeapObjects:  mem.HeapObjects,
+			Sys:          mem.Sys,
+			NumGC:        mem.NumGC,
+			PauseTotalNs: mem.PauseTotalNs,
+		})
+	})
+	return r
+}
+
+// MountHealth serve the probes of registry on r, the public router serves
+// them when the admin listener is disabled
+func MountHealth(r chi.Router, registry *health.Registry) {
+	r.Get("/health", registry.ReadyHandler)
+	r.Get("/health/live", registry.LiveHandler)
+	r.Get("/health/ready", registry.ReadyHandler)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,55 @@
+package v1
+
+import (
+	stdErrors "errors"
+	"go-example/internal/auth"
+	"go-example/internal/dto"
+	"go-example/internal/errors"
+	"go-example/internal/repository"
+	"go-example/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthAPI api controller of authentication
+type AuthAPI interface {
+	Login(*gin.Context)
+}
+
+type authAPI struct {
+	service services.AuthService
+}
+
+// NewAuthAPI create authAPI
+func NewAuthAPI(users repository.UserRepository, tokens *auth.Tokens) AuthAPI {
+	return &authAPI{service: services.NewAuthService(users, tokens)}
+}
+
+// Login godoc
+// @Title Login
+// @Summary Issue an access token for valid credentials
+// @ID login
+// @Accept  json
+// @Produce  json
+// @Param credentials body dto.LoginRequest true "Credentials"
+// @Success 200 {object} dto.DataReply{data=dto.TokenReply}
+// @Failure 401 {object} dto.ErrorReply "Invalid username or password"
+// @Router /auth/login [post]
+func (a authAPI) Login(ctx *gin.Context) {
+	var req dto.LoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(errors.NewError(http.StatusBadRequest, err.Error()))
+		return
+	}
+	token, err := a.service.Login(ctx.Request.Context(), req)
+	if err != nil {
+		if stdErrors.Is(err, services.ErrInvalidCredentials) {
+			ctx.Error(errors.NewError(http.StatusUnauthorized, err.Error()))
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.DataReply{Data: token})
+}
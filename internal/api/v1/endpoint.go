@@ -1,18 +1,42 @@
 package v1
 
 import (
+	"go-example/internal/auth"
+	"go-example/internal/repository"
+
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-// RegisterRouterAPIV1 group for api/v1/*
-func RegisterRouterAPIV1(router *gin.RouterGroup, db *gorm.DB) {
-	userAPI := NewUserAPI(db)
-	router.GET("/users", userAPI.GetAllUser)
-	router.GET("/users/:id", userAPI.GetUser)
+// RegisterRouterAPIV1 group for api/v1/*, data is stored in db
+func RegisterRouterAPIV1(router *gin.RouterGroup, db *gorm.DB, tokens *auth.Tokens, policy auth.Policy) {
+	RegisterRoutes(router,
+		repository.NewGormUserRepository(db),
+		repository.NewGormProductRepository(db),
+		tokens, policy)
+}
+
+// RegisterRoutes group for api/v1/* on top of any repositories
+func RegisterRoutes(router *gin.RouterGroup, users repository.UserRepository, products repository.ProductRepository, tokens *auth.Tokens, policy auth.Policy) {
+	authAPI := NewAuthAPI(users, tokens)
+	router.POST("/auth/login", authAPI.Login)
+
+	// every other route requires an access token
+	protected := router.Group("", auth.GinRequired(tokens))
+
+	userAPI := NewUserAPI(users, policy)
+	protected.GET("/users", userAPI.GetAllUser)
+	protected.GET("/users/:id", userAPI.GetUser)
+	protected.POST("/users", auth.GinAuthorize(policy, auth.ActionCreateUser), userAPI.CreateUser)
+	protected.PATCH("/users/:id", auth.GinAuthorizeOwner(policy, auth.ActionUpdateUser, "id"), userAPI.UpdateUser)
+	protected.DELETE("/users/:id", auth.GinAuthorize(policy, auth.ActionDeleteUser), userAPI.DeleteUser)
 
-	prodAPI := NewProductAPI(db)
-	router.GET("/products", prodAPI.FindAll)
-	router.GET("/products/:id", prodAPI.GetProduct)
-	router.DELETE("/products/:id", prodAPI.DeleteProduct)
+	prodAPI := NewProductAPI(products)
+	protected.GET("/products", prodAPI.FindAll)
+	protected.GET("/products/search", prodAPI.SearchProducts)
+	protected.GET("/products/:id", prodAPI.GetProduct)
+	protected.POST("/products", auth.GinAuthorize(policy, auth.ActionWriteProduct), prodAPI.CreateProduct)
+	protected.PUT("/products/:id", auth.GinAuthorize(policy, auth.ActionWriteProduct), prodAPI.UpdateProduct)
+	protected.PATCH("/products/:id", auth.GinAuthorize(policy, auth.ActionWriteProduct), prodAPI.PatchProduct)
+	protected.DELETE("/products/:id", auth.GinAuthorize(policy, auth.ActionDeleteProduct), prodAPI.DeleteProduct)
 }
@@ -0,0 +1,182 @@
+package v1_test
+
+import (
+	"context"
+	"encoding/json"
+	v1 "go-example/internal/api/v1"
+	"go-example/internal/auth"
+	"go-example/internal/dto"
+	"go-example/internal/entities"
+	"go-example/internal/errors"
+	"go-example/internal/repository"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// RoutesTestSuite serve the api on in-memory repositories, no SQL involved
+type RoutesTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	token  string
+}
+
+func (s *RoutesTestSuite) SetupTest() {
+	admin := entities.User{Username: "admin", Roles: []entities.Role{{Name: "admin"}}}
+	admin.ID = "1"
+	s.Require().NoError(admin.SetPassword("S3cretPassw0rd"))
+	bob := entities.User{Username: "bob", Email: "bob@example.com"}
+	bob.ID = "2"
+	s.Require().NoError(bob.SetPassword("S3cretPassw0rd"))
+	manager := entities.User{Username: "manager", Roles: []entities.Role{{Name: "manager"}}}
+	manager.ID = "3"
+	s.Require().NoError(manager.SetPassword("S3cretPassw0rd"))
+
+	tokens, err := auth.New(auth.Config{
+		Issuer:     "test",
+		TTL:        time.Minute,
+		SigningKey: "test",
+		Keys:       map[string]auth.KeyConfig{"test": {Secret: "secret"}},
+	})
+	require.NoError(s.T(), err)
+
+	router := gin.New()
+	router.Use(errors.GinError())
+	v1.RegisterRoutes(router.Group("/api/v1"),
+		repository.NewMemoryUserRepository(admin, bob, manager),
+		repository.NewMemoryProductRepository(),
+		tokens, auth.NewPolicy(auth.Policy{auth.ActionUpdateUser: {"admin", "manager"}}))
+	s.router = router
+	s.login("admin")
+}
+
+// login use the access token of username for the next requests
+func (s *RoutesTestSuite) login(username string) {
+	s.token = ""
+	res := s.serve("POST", "/api/v1/auth/login", `{"username":"`+username+`","password":"S3cretPassw0rd"}`)
+	s.Require().Equal(http.StatusOK, res.Code, res.Body.String())
+	reply := struct {
+		Data dto.TokenReply `json:"data"`
+	}{}
+	s.Require().NoError(json.Unmarshal(res.Body.Bytes(), &reply))
+	s.token = reply.Data.AccessToken
+}
+
+func (s *RoutesTestSuite) serve(method, url, body string) *httptest.ResponseRecorder {
+	return s.serveContext(context.Background(), method, url, body)
+}
+
+func (s *RoutesTestSuite) serveContext(ctx context.Context, method, url, body string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	s.router.ServeHTTP(res, req)
+	return res
+}
+
+func (s *RoutesTestSuite) TestUserLifecycle() {
+	body := `{"username":"utain","email":"utain@example.com","password":"S3cretPassw0rd"}`
+	res := s.serve("POST", "/api/v1/users", body)
+	s.Require().Equal(http.StatusCreated, res.Code, res.Body.String())
+
+	res = s.serve("POST", "/api/v1/users", body)
+	s.Equal(http.StatusConflict, res.Code, "Status must be 409:Conflict")
+
+	res = s.serve("GET", "/api/v1/users?filter=username~uta", "")
+	s.Equal(http.StatusOK, res.Code)
+	s.Contains(res.Body.String(), `"username":"utain"`)
+	s.NotContains(res.Body.String(), `"username":"admin"`)
+}
+
+func (s *RoutesTestSuite) TestProductLifecycle() {
+	res := s.serve("POST", "/api/v1/products", `{"name":"Red pen","code":"P1","props":[{"key":"color","value":"red"}]}`)
+	s.Require().Equal(http.StatusCreated, res.Code, res.Body.String())
+	reply := struct {
+		Data entities.Product `json:"data"`
+	}{}
+	s.Require().NoError(json.Unmarshal(res.Body.Bytes(), &reply))
+	id := reply.Data.ID
+
+	res = s.serve("POST", "/api/v1/products", `{"name":"Blue pen","code":"P1"}`)
+	s.Equal(http.StatusConflict, res.Code, "Status must be 409:Conflict")
+
+	res = s.serve("GET", "/api/v1/products/search?q=red", "")
+	s.Equal(http.StatusOK, res.Code)
+	s.Contains(res.Body.String(), `\u003cmark\u003eRed\u003c/mark\u003e`)
+
+	res = s.serve("DELETE", "/api/v1/products/"+id, "")
+	s.Equal(http.StatusAccepted, res.Code)
+	res = s.serve("DELETE", "/api/v1/products/"+id, "")
+	s.Equal(http.StatusNotFound, res.Code, "Status must be 404:Not Found")
+}
+
+func (s *RoutesTestSuite) TestNonAdminWrites() {
+	s.login("bob")
+	forbidden := []struct{ method, url, body string }{
+		{"POST", "/api/v1/users", `{"username":"eve","email":"eve@example.com","password":"S3cretPassw0rd"}`},
+		{"PATCH", "/api/v1/users/1", `{"password":"0wnedPassw0rd"}`},
+		{"PATCH", "/api/v1/users/1", `{"firstname":"Eve"}`},
+		{"DELETE", "/api/v1/users/1", ""},
+		{"POST", "/api/v1/products", `{"name":"Red pen","code":"P1"}`},
+		{"PUT", "/api/v1/products/1", `{"name":"Red pen","code":"P1"}`},
+		{"PATCH", "/api/v1/products/1", `{"name":"Red pen"}`},
+		{"DELETE", "/api/v1/products/1", ""},
+	}
+	for _, req := range forbidden {
+		res := s.serve(req.method, req.url, req.body)
+		s.Equal(http.StatusForbidden, res.Code, "%s %s must be 403:Forbidden", req.method, req.url)
+	}
+
+	res := s.serve("PATCH", "/api/v1/users/2", `{"firstname":"Bob","password":"N3wPassw0rd!"}`)
+	s.Equal(http.StatusOK, res.Code, "users may update themselves")
+}
+
+func (s *RoutesTestSuite) TestUpdateCredentials() {
+	s.login("manager")
+	res := s.serve("PATCH", "/api/v1/users/1", `{"firstname":"Ada"}`)
+	s.Equal(http.StatusOK, res.Code, res.Body.String())
+	res = s.serve("PATCH", "/api/v1/users/1", `{"password":"0wnedPassw0rd"}`)
+	s.Equal(http.StatusForbidden, res.Code, "only admins change the password of others")
+
+	s.login("admin")
+	res = s.serve("PATCH", "/api/v1/users/2", `{"password":"N3wPassw0rd!"}`)
+	s.Equal(http.StatusOK, res.Code, res.Body.String())
+}
+
+func (s *RoutesTestSuite) TestContextErrors() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res := s.serveContext(ctx, "GET", "/api/v1/products", "")
+	s.Equal(errors.StatusClientClosedRequest, res.Code, "Status must be 499:Client Closed Request")
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	res = s.serveContext(ctx, "DELETE", "/api/v1/products/1", "")
+	s.Equal(http.StatusGatewayTimeout, res.Code, "Status must be 504:Gateway Timeout")
+	s.Contains(res.Body.String(), "request timeout")
+
+	for _, url := range []string{"/api/v1/products/1", "/api/v1/users/1"} {
+		res = s.serveContext(ctx, "GET", url, "")
+		s.Equal(http.StatusGatewayTimeout, res.Code, "GET %s must be 504:Gateway Timeout", url)
+
+		canceled, cancel := context.WithCancel(context.Background())
+		cancel()
+		res = s.serveContext(canceled, "GET", url, "")
+		s.Equal(errors.StatusClientClosedRequest, res.Code, "GET %s must be 499:Client Closed Request", url)
+	}
+	res = s.serve("GET", "/api/v1/products/unknown", "")
+	s.Equal(http.StatusNotFound, res.Code, "Status must be 404:Not Found")
+}
+
+func TestRoutesTestSuite(t *testing.T) {
+	suite.Run(t, new(RoutesTestSuite))
+}
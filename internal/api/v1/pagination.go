@@ -0,0 +1,89 @@
+package v1
+
+import (
+	stdErrors "errors"
+	"go-example/internal/dto"
+	"go-example/internal/errors"
+	"go-example/internal/queryspec"
+	"go-example/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindPageable parse the offset, cursor, limit and q query parameters
+func bindPageable(ctx *gin.Context) (dto.Pageable, error) {
+	var pageable dto.Pageable
+	if err := ctx.ShouldBindQuery(&pageable); err != nil {
+		return pageable, errors.NewError(http.StatusBadRequest, err.Error())
+	}
+	pageable.Normalize()
+	return pageable, nil
+}
+
+// bindQuerySpec parse the filter and sort query parameters against the
+// allowlist of schema
+func bindQuerySpec(ctx *gin.Context, schema queryspec.Schema) (*queryspec.Spec, error) {
+	spec, err := queryspec.Parse(schema, ctx.Query("filter"), ctx.Query("sort"))
+	if err != nil {
+		return nil, errors.NewError(http.StatusBadRequest, err.Error())
+	}
+	return spec, nil
+}
+
+// pageMeta describe the page of a listing with links to the adjacent pages,
+// cursor pagination only link the next page
+func pageMeta(ctx *gin.Context, pageable dto.Pageable, page *dto.Page) *dto.Meta {
+	meta := &dto.Meta{Limit: pageable.Limit}
+	if pageable.Cursor != nil {
+		meta.NextCursor = page.NextCursor
+		if page.NextCursor != "" {
+			meta.Next = pageLink(ctx, map[string]string{
+				"cursor": page.NextCursor,
+				"limit":  strconv.Itoa(pageable.Limit),
+			})
+		}
+		return meta
+	}
+
+	offset := pageable.Offset
+	meta.Total = page.Total
+	meta.Offset = &offset
+	if next := pageable.Offset + pageable.Limit; page.Total != nil && int64(next) < *page.Total {
+		meta.Next = pageLink(ctx, map[string]string{
+			"offset": strconv.Itoa(next),
+			"limit":  strconv.Itoa(pageable.Limit),
+		})
+	}
+	if pageable.Offset > 0 {
+		prev := pageable.Offset - pageable.Limit
+		if prev < 0 {
+			prev = 0
+		}
+		meta.Prev = pageLink(ctx, map[string]string{
+			"offset": strconv.Itoa(prev),
+			"limit":  strconv.Itoa(pageable.Limit),
+		})
+	}
+	return meta
+}
+
+// pageLink return the request uri with params replaced
+func pageLink(ctx *gin.Context, params map[string]string) string {
+	u := *ctx.Request.URL
+	query := u.Query()
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
+
+// pageError map pagination errors of the services to bad requests
+func pageError(err error) error {
+	if stdErrors.Is(err, services.ErrInvalidCursor) || stdErrors.Is(err, services.ErrSortWithCursor) {
+		return errors.NewError(http.StatusBadRequest, err.Error())
+	}
+	return err
+}
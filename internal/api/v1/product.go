@@ -1,19 +1,24 @@
 package v1
 
 import (
+	stdErrors "errors"
 	"go-example/internal/dto"
 	"go-example/internal/errors"
+	"go-example/internal/repository"
 	"go-example/internal/services"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 //ProductAPI api controller of produces
 type ProductAPI interface {
 	FindAll(*gin.Context)
+	SearchProducts(*gin.Context)
 	GetProduct(*gin.Context)
+	CreateProduct(*gin.Context)
+	UpdateProduct(*gin.Context)
+	PatchProduct(*gin.Context)
 	DeleteProduct(*gin.Context)
 }
 
@@ -22,28 +27,184 @@ type productAPI struct {
 }
 
 // NewProductAPI get product service instance
-func NewProductAPI(db *gorm.DB) ProductAPI {
-	return &productAPI{service: services.NewProductService(db)}
+func NewProductAPI(products repository.ProductRepository) ProductAPI {
+	return &productAPI{service: services.NewProductService(products)}
 }
 
+// FindAll godoc
+// @Title FindAll
+// @Summary List products
+// @ID find-all-products
+// @Produce  json
+// @Param offset query int false "Number of products to skip"
+// @Param filter query string false "Comma separated conditions, e.g. price>=100,code~ABC"
+// @Param sort query string false "Comma separated fields, prefixed by - for descending order"
+// @Param cursor query string false "Cursor of the page, empty for the first page, select cursor pagination"
+// @Param limit query int false "Page size, at most 100"
+// @Param q query string false "Search in name and code"
+// @Success 200 {object} dto.DataReply{data=[]entities.Product}
+// @Failure 400 {object} dto.ErrorReply "Invalid pagination"
+// @Router /products [get]
 func (p productAPI) FindAll(ctx *gin.Context) {
-	products, _ := p.service.FindAll(dto.Pageable{})
-	ctx.JSON(http.StatusOK, dto.DataReply{Data: products})
+	pageable, err := bindPageable(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	spec, err := bindQuerySpec(ctx, services.ProductQuerySchema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	products, page, err := p.service.FindAll(ctx.Request.Context(), pageable, spec)
+	if err != nil {
+		ctx.Error(pageError(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.DataReply{Data: products, Meta: pageMeta(ctx, pageable, page)})
+}
+
+// SearchProducts godoc
+// @Title SearchProducts
+// @Summary Full-text search of products by name, code and prop values
+// @ID search-products
+// @Produce  json
+// @Param q query string true "Words to search"
+// @Param prefix query bool false "Match words starting with the searched words"
+// @Param offset query int false "Number of products to skip"
+// @Param limit query int false "Page size, at most 100"
+// @Success 200 {object} dto.DataReply{data=[]repository.ProductSearchHit}
+// @Failure 400 {object} dto.ErrorReply "Invalid search"
+// @Router /products/search [get]
+func (p productAPI) SearchProducts(ctx *gin.Context) {
+	var req dto.SearchRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(errors.NewError(http.StatusBadRequest, err.Error()))
+		return
+	}
+	hits, page, err := p.service.SearchProducts(ctx.Request.Context(), req)
+	if err != nil {
+		if stdErrors.Is(err, services.ErrEmptySearch) {
+			err = errors.NewError(http.StatusBadRequest, err.Error())
+		}
+		ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.DataReply{Data: hits, Meta: pageMeta(ctx, req.Pageable(), page)})
 }
 
 func (p productAPI) GetProduct(ctx *gin.Context) {
-	product, err := p.service.GetProduct(ctx.Param("id"))
+	product, err := p.service.GetProduct(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		ctx.Error(productError(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.DataReply{Data: product})
+}
+
+// CreateProduct godoc
+// @Title CreateProduct
+// @Summary Create a product with its props
+// @ID create-product
+// @Accept  json
+// @Produce  json
+// @Param product body dto.ProductRequest true "Product"
+// @Success 201 {object} dto.DataReply{data=entities.Product}
+// @Failure 400 {object} dto.ErrorReply "Invalid product"
+// @Failure 409 {object} dto.ErrorReply "Product code already exists"
+// @Router /products [post]
+func (p productAPI) CreateProduct(ctx *gin.Context) {
+	var req dto.ProductRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(errors.NewError(http.StatusBadRequest, err.Error()))
+		return
+	}
+	product, err := p.service.CreateProduct(ctx.Request.Context(), req)
+	if err != nil {
+		ctx.Error(productError(err))
+		return
+	}
+	ctx.JSON(http.StatusCreated, dto.DataReply{Data: product})
+}
+
+// UpdateProduct godoc
+// @Title UpdateProduct
+// @Summary Replace a product and its props
+// @ID update-product
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Product ID"
+// @Param product body dto.ProductRequest true "Product"
+// @Success 200 {object} dto.DataReply{data=entities.Product}
+// @Failure 400 {object} dto.ErrorReply "Invalid product"
+// @Failure 404 {object} dto.ErrorReply "Product not found"
+// @Failure 409 {object} dto.ErrorReply "Product code already exists"
+// @Router /products/{id} [put]
+func (p productAPI) UpdateProduct(ctx *gin.Context) {
+	var req dto.ProductRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(errors.NewError(http.StatusBadRequest, err.Error()))
+		return
+	}
+	product, err := p.service.UpdateProduct(ctx.Request.Context(), ctx.Param("id"), req)
 	if err != nil {
-		ctx.Error(errors.NewError(http.StatusNotFound, err.Error()))
+		ctx.Error(productError(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.DataReply{Data: product})
+}
+
+// PatchProduct godoc
+// @Title PatchProduct
+// @Summary Update some fields of a product and merge its props
+// @ID patch-product
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Product ID"
+// @Param product body dto.PatchProductRequest true "Changes"
+// @Success 200 {object} dto.DataReply{data=entities.Product}
+// @Failure 400 {object} dto.ErrorReply "Invalid product"
+// @Failure 404 {object} dto.ErrorReply "Product not found"
+// @Failure 409 {object} dto.ErrorReply "Product code already exists"
+// @Router /products/{id} [patch]
+func (p productAPI) PatchProduct(ctx *gin.Context) {
+	var req dto.PatchProductRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(errors.NewError(http.StatusBadRequest, err.Error()))
+		return
+	}
+	product, err := p.service.PatchProduct(ctx.Request.Context(), ctx.Param("id"), req)
+	if err != nil {
+		ctx.Error(productError(err))
 		return
 	}
 	ctx.JSON(http.StatusOK, dto.DataReply{Data: product})
 }
 
 func (p productAPI) DeleteProduct(ctx *gin.Context) {
-	if err := p.service.DeleteProduct(ctx.Param("id")); err != nil {
+	if err := p.service.DeleteProduct(ctx.Request.Context(), ctx.Param("id")); err != nil {
+		if stdErrors.Is(err, services.ErrProductNotFound) {
+			ctx.Error(productError(err))
+			return
+		}
+		if ctxErr := errors.FromContext(err); ctxErr != nil {
+			ctx.Error(ctxErr)
+			return
+		}
 		ctx.Error(errors.NewError(http.StatusBadRequest, err.Error()))
 		return
 	}
 	ctx.Status(http.StatusAccepted)
 }
+
+// productError map service errors to http errors, unknown errors are reported
+// as internal errors
+func productError(err error) error {
+	switch {
+	case stdErrors.Is(err, services.ErrProductNotFound):
+		return errors.NewError(http.StatusNotFound, err.Error())
+	case stdErrors.Is(err, services.ErrProductExists):
+		return errors.NewError(http.StatusConflict, err.Error())
+	}
+	return err
+}
@@ -1,8 +1,182 @@
 package v1_test
 
 import (
+	"database/sql"
+	v1 "go-example/internal/api/v1"
+	"go-example/internal/auth"
+	"go-example/internal/errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
 )
 
 func TestHTTPGetProducts(t *testing.T) {
 }
+
+type ProductAPITestSuite struct {
+	suite.Suite
+	DB   *gorm.DB
+	mock sqlmock.Sqlmock
+
+	router *gin.Engine
+	tokens *auth.Tokens
+	token  string
+}
+
+func (s *ProductAPITestSuite) SetupTest() {
+	var (
+		db  *sql.DB
+		err error
+	)
+	db, s.mock, err = sqlmock.New()
+	require.NoError(s.T(), err)
+
+	s.DB, err = gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}))
+	require.NoError(s.T(), err)
+
+	s.tokens, err = auth.New(auth.Config{
+		Issuer:     "test",
+		TTL:        time.Minute,
+		SigningKey: "test",
+		Keys:       map[string]auth.KeyConfig{"test": {Secret: "secret"}},
+	})
+	require.NoError(s.T(), err)
+	s.token, _, err = s.tokens.Issue(auth.Principal{ID: "1", Username: "utain", Roles: []string{"editor"}})
+	require.NoError(s.T(), err)
+
+	router := gin.New()
+	router.Use(errors.GinError())
+	policy := auth.NewPolicy(auth.Policy{auth.ActionWriteProduct: {"admin", "editor"}})
+	v1.RegisterRouterAPIV1(router.Group("/api/v1"), s.DB, s.tokens, policy)
+	s.router = router
+}
+
+func (s *ProductAPITestSuite) TearDownTest() {
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+func (s *ProductAPITestSuite) serve(method, url, body string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	s.router.ServeHTTP(res, req)
+	return res
+}
+
+func (s *ProductAPITestSuite) TestHTTPCreateProduct() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(`SELECT count\(\*\) FROM "products" WHERE code = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	s.mock.ExpectExec(`INSERT INTO "products"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectExec(`INSERT INTO "product_props"`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "color", "red", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+	s.mock.ExpectQuery(`SELECT \* FROM "products"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "code"}).
+			AddRow("1", "Pen", "P-1"))
+	s.mock.ExpectQuery(`SELECT \* FROM "product_props"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "key", "value", "product_ref"}).
+			AddRow("2", "color", "red", "1"))
+
+	res := s.serve("POST", "/api/v1/products", `{"name":"Pen","code":"P-1","price":10,"props":[{"key":"color","value":"red"}]}`)
+	s.Equal(http.StatusCreated, res.Code, "Status must be 201:Created")
+	s.Contains(res.Body.String(), `"color"`)
+}
+
+func (s *ProductAPITestSuite) TestHTTPCreateInvalidProduct() {
+	res := s.serve("POST", "/api/v1/products", `{"code":"P-1"}`)
+	s.Equal(http.StatusBadRequest, res.Code, "Status must be 400:BadRequest")
+}
+
+func (s *ProductAPITestSuite) TestHTTPCreateProductWithoutToken() {
+	s.token = ""
+	res := s.serve("POST", "/api/v1/products", `{"name":"Pen","code":"P-1"}`)
+	s.Equal(http.StatusUnauthorized, res.Code, "Status must be 401:Unauthorized")
+}
+
+func (s *ProductAPITestSuite) TestHTTPDeleteProductRequiresAdmin() {
+	res := s.serve("DELETE", "/api/v1/products/1", "")
+	s.Equal(http.StatusForbidden, res.Code, "Status must be 403:Forbidden")
+	s.Contains(res.Body.String(), "permission denied")
+
+	var err error
+	s.token, _, err = s.tokens.Issue(auth.Principal{ID: "2", Username: "admin", Roles: []string{"admin"}})
+	s.Require().NoError(err)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(`UPDATE "products" SET "deleted_at"`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	res = s.serve("DELETE", "/api/v1/products/1", "")
+	s.Equal(http.StatusAccepted, res.Code, "Status must be 202:Accepted")
+}
+
+func (s *ProductAPITestSuite) TestHTTPFindAllWithFilter() {
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT count(*) FROM "products" WHERE "price" >= $1 AND "products"."deleted_at" IS NULL`)).
+		WithArgs(float64(100)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT * FROM "products" WHERE "price" >= $1 AND "products"."deleted_at" IS NULL ORDER BY "price" DESC,created_at,id LIMIT 20`)).
+		WithArgs(float64(100)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price"}).AddRow("1", "Pen", 120))
+
+	res := s.serve("GET", "/api/v1/products?filter=price>=100&sort=-price", "")
+	s.Equal(http.StatusOK, res.Code, "Status must be 200:OK")
+}
+
+func (s *ProductAPITestSuite) TestHTTPFindAllWithUnknownFilter() {
+	res := s.serve("GET", "/api/v1/products?filter=colour=red", "")
+	s.Equal(http.StatusBadRequest, res.Code, "Status must be 400:BadRequest")
+	s.Contains(res.Body.String(), `unknown field \"colour\"`)
+}
+
+func (s *ProductAPITestSuite) TestHTTPPatchUnknownProduct() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(`SELECT \* FROM "products" .* FOR UPDATE`).
+		WillReturnError(gorm.ErrRecordNotFound)
+	s.mock.ExpectRollback()
+
+	res := s.serve("PATCH", "/api/v1/products/x", `{"name":"Pencil"}`)
+	s.Equal(http.StatusNotFound, res.Code, "Status must be 404:NotFound")
+}
+
+func (s *ProductAPITestSuite) TestHTTPSearchProducts() {
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM products, to_tsquery($1, $2) query`)).
+		WithArgs("simple", "blue:* & pen:*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`ts_rank(products.search_vector, query) AS rank`)).
+		WithArgs("simple", sqlmock.AnyArg(), "simple", "blue:* & pen:*", 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "code", "rank", "snippet"}).
+			AddRow("1", "Blue pen", "BP-1", 0.6, "<mark>Blue</mark> <mark>pen</mark> BP-1"))
+
+	res := s.serve("GET", "/api/v1/products/search?q=blue+pen'&prefix=true", "")
+	s.Equal(http.StatusOK, res.Code, "Status must be 200:OK")
+	s.Contains(res.Body.String(), `"rank":0.6`)
+	s.Contains(res.Body.String(), `"snippet":"\u003cmark\u003eBlue`)
+	s.Contains(res.Body.String(), `"total":1`)
+}
+
+func (s *ProductAPITestSuite) TestHTTPSearchProductsWithoutWords() {
+	res := s.serve("GET", "/api/v1/products/search?q=%26!", "")
+	s.Equal(http.StatusBadRequest, res.Code, "Status must be 400:BadRequest")
+}
+
+func TestProductAPITestSuite(t *testing.T) {
+	suite.Run(t, new(ProductAPITestSuite))
+}
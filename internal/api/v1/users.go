@@ -1,30 +1,36 @@
 package v1
 
 import (
+	stdErrors "errors"
+	"go-example/internal/auth"
 	"go-example/internal/dto"
 	_ "go-example/internal/entities"
 	"go-example/internal/errors"
+	"go-example/internal/repository"
 	"go-example/internal/services"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-// NewUserAPI create userService
-func NewUserAPI(db *gorm.DB) UserAPI {
-	return &userAPI{service: services.NewUserService(db)}
+// NewUserAPI create userService, policy guards the changes of credentials
+func NewUserAPI(users repository.UserRepository, policy auth.Policy) UserAPI {
+	return &userAPI{service: services.NewUserService(users), policy: policy}
 }
 
 // UserAPI interface
 type UserAPI interface {
 	GetAllUser(c *gin.Context)
 	GetUser(c *gin.Context)
+	CreateUser(c *gin.Context)
+	UpdateUser(c *gin.Context)
+	DeleteUser(c *gin.Context)
 }
 
 // userAPI is a service private
 type userAPI struct {
 	service services.UserService
+	policy  auth.Policy
 }
 
 // GetAllUser godoc
@@ -34,27 +40,122 @@ type userAPI struct {
 // @ID get-all-users
 // @Accept  json
 // @Produce  json
+// @Param offset query int false "Number of users to skip"
+// @Param filter query string false "Comma separated conditions, e.g. username~uta,createdAt>=2023-01-01T00:00:00Z"
+// @Param sort query string false "Comma separated fields, prefixed by - for descending order"
+// @Param cursor query string false "Cursor of the page, empty for the first page, select cursor pagination"
+// @Param limit query int false "Page size, at most 100"
+// @Param q query string false "Search in username and email"
 // @Success 200 {object} dto.DataReply{data=[]entities.User}
+// @Failure 400 {object} dto.ErrorReply "Invalid pagination"
 // @Failure 500 {object} dto.ErrorReply "Unknown error"
 // @Router /users [get]
 func (p *userAPI) GetAllUser(ctx *gin.Context) {
-	// var users *[]entities.User
-	// var err error
-	users, err := p.service.GetAllUser(dto.Pageable{})
+	pageable, err := bindPageable(ctx)
 	if err != nil {
-		ctx.Error(errors.NewError(http.StatusBadRequest, err.Error()))
+		ctx.Error(err)
+		return
+	}
+	spec, err := bindQuerySpec(ctx, services.UserQuerySchema)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	users, page, err := p.service.GetAllUser(ctx.Request.Context(), pageable, spec)
+	if err != nil {
+		ctx.Error(pageError(err))
 		return
 	}
-	ctx.JSON(http.StatusOK, dto.DataReply{Data: users})
+	ctx.JSON(http.StatusOK, dto.DataReply{Data: users, Meta: pageMeta(ctx, pageable, page)})
 }
 
 // GetUser return only one User
 func (p *userAPI) GetUser(ctx *gin.Context) {
 	id := ctx.Param("id")
-	user, err := p.service.GetUser(id)
+	user, err := p.service.GetUser(ctx.Request.Context(), id)
 	if err != nil {
-		ctx.Error(errors.NewError(http.StatusNotFound, err.Error()))
+		ctx.Error(userError(err))
 		return
 	}
 	ctx.JSON(http.StatusOK, dto.DataReply{Data: user})
 }
+
+// CreateUser godoc
+// @Title CreateUser
+// @Summary Create a user
+// @ID create-user
+// @Accept  json
+// @Produce  json
+// @Param user body dto.CreateUserRequest true "User"
+// @Success 201 {object} dto.DataReply{data=entities.User}
+// @Failure 400 {object} dto.ErrorReply "Invalid user"
+// @Failure 409 {object} dto.ErrorReply "Username or email already exists"
+// @Router /users [post]
+func (p *userAPI) CreateUser(ctx *gin.Context) {
+	var req dto.CreateUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(errors.NewError(http.StatusBadRequest, err.Error()))
+		return
+	}
+	user, err := p.service.CreateUser(ctx.Request.Context(), req)
+	if err != nil {
+		ctx.Error(userError(err))
+		return
+	}
+	ctx.JSON(http.StatusCreated, dto.DataReply{Data: user})
+}
+
+// UpdateUser godoc
+// @Title UpdateUser
+// @Summary Update some fields of a user
+// @ID update-user
+// @Accept  json
+// @Produce  json
+// @Param id path string true "User ID"
+// @Param user body dto.UpdateUserRequest true "Changes"
+// @Success 200 {object} dto.DataReply{data=entities.User}
+// @Failure 400 {object} dto.ErrorReply "Invalid user"
+// @Failure 403 {object} dto.ErrorReply "Password of another user"
+// @Failure 404 {object} dto.ErrorReply "User not found"
+// @Failure 409 {object} dto.ErrorReply "Email already exists"
+// @Router /users/{id} [patch]
+func (p *userAPI) UpdateUser(ctx *gin.Context) {
+	var req dto.UpdateUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(errors.NewError(http.StatusBadRequest, err.Error()))
+		return
+	}
+	// users allowed to update others may still not take over their accounts
+	if principal := auth.PrincipalFromContext(ctx.Request.Context()); req.Password != nil &&
+		(principal == nil || principal.ID != ctx.Param("id") && !p.policy.Allowed(principal, auth.ActionUpdateCredentials)) {
+		ctx.Error(errors.NewError(http.StatusForbidden, "permission denied"))
+		return
+	}
+	user, err := p.service.UpdateUser(ctx.Request.Context(), ctx.Param("id"), req)
+	if err != nil {
+		ctx.Error(userError(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.DataReply{Data: user})
+}
+
+// DeleteUser soft delete a user
+func (p *userAPI) DeleteUser(ctx *gin.Context) {
+	if err := p.service.DeleteUser(ctx.Request.Context(), ctx.Param("id")); err != nil {
+		ctx.Error(userError(err))
+		return
+	}
+	ctx.Status(http.StatusAccepted)
+}
+
+// userError map service errors to http errors, unknown errors are reported
+// as internal errors
+func userError(err error) error {
+	switch {
+	case stdErrors.Is(err, services.ErrUserNotFound):
+		return errors.NewError(http.StatusNotFound, err.Error())
+	case stdErrors.Is(err, services.ErrUserExists):
+		return errors.NewError(http.StatusConflict, err.Error())
+	}
+	return err
+}
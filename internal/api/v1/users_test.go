@@ -2,13 +2,19 @@ package v1_test
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	v1 "go-example/internal/api/v1"
+	"go-example/internal/auth"
+	"go-example/internal/dto"
 	"go-example/internal/errors"
+	"go-example/internal/repository"
 	"net/http"
 	"net/http/httptest"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
@@ -40,7 +46,9 @@ func (s *UserAPITestSuite) SetupSuite() {
 		Conn: db,
 	}))
 	require.NoError(s.T(), err)
-	s.api = v1.NewUserAPI(s.DB)
+	s.api = v1.NewUserAPI(repository.NewGormUserRepository(s.DB), auth.DefaultPolicy)
+	// each test set the expectations of its own queries
+	s.mock.MatchExpectationsInOrder(false)
 	s.mock.ExpectQuery(
 		regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."id" = $1 AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT 1`)).
 		WithArgs("1").
@@ -58,10 +66,72 @@ func (s *UserAPITestSuite) SetupSuite() {
 }
 
 func (s *UserAPITestSuite) TestHTTPGetAllUsers() {
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT count(*) FROM "users" WHERE (username ILIKE $1 OR email ILIKE $2) AND "users"."deleted_at" IS NULL`)).
+		WithArgs("%uta%", "%uta%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT * FROM "users" WHERE (username ILIKE $1 OR email ILIKE $2) AND "users"."deleted_at" IS NULL ORDER BY created_at,id LIMIT 1 OFFSET 1`)).
+		WithArgs("%uta%", "%uta%").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).
+			AddRow("2", "utain2"))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/users?offset=1&limit=1&q=uta", nil)
+	s.router.ServeHTTP(res, req)
+	s.Equal(http.StatusOK, res.Code, "Status must be 200:OK")
+	s.Contains(res.Body.String(), `"total":3`)
+	s.Contains(res.Body.String(), `"next":"/api/v1/users?limit=1\u0026offset=2\u0026q=uta"`)
+	s.Contains(res.Body.String(), `"prev":"/api/v1/users?limit=1\u0026offset=0\u0026q=uta"`)
+}
+
+func (s *UserAPITestSuite) TestHTTPGetAllUsersCursor() {
+	createdAt := time.Date(2023, 1, 2, 3, 4, 5, 6000, time.UTC)
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT * FROM "users" WHERE "users"."deleted_at" IS NULL ORDER BY created_at,id LIMIT 2`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "created_at"}).
+			AddRow("a", "utain", createdAt).
+			AddRow("b", "utain2", createdAt))
+
 	res := httptest.NewRecorder()
-	req, _ := http.NewRequest("GET", "/api/v1/users", nil)
+	req, _ := http.NewRequest("GET", "/api/v1/users?cursor=&limit=1", nil)
+	s.router.ServeHTTP(res, req)
+	s.Require().Equal(http.StatusOK, res.Code, "Status must be 200:OK")
+
+	var reply struct {
+		Data []map[string]interface{} `json:"data"`
+		Meta dto.Meta                 `json:"meta"`
+	}
+	s.Require().NoError(json.Unmarshal(res.Body.Bytes(), &reply))
+	s.Len(reply.Data, 1)
+	s.Nil(reply.Meta.Total, "cursor pagination must not count rows")
+	s.Require().NotEmpty(reply.Meta.NextCursor)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT * FROM "users" WHERE (created_at, id) > ($1, $2) AND "users"."deleted_at" IS NULL ORDER BY created_at,id LIMIT 2`)).
+		WithArgs(createdAt, "a").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "created_at"}).
+			AddRow("b", "utain2", createdAt))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/users?limit=1&cursor="+reply.Meta.NextCursor, nil)
 	s.router.ServeHTTP(res, req)
 	s.Equal(http.StatusOK, res.Code, "Status must be 200:OK")
+	s.NotContains(res.Body.String(), "next_cursor", "last page has no next cursor")
+}
+
+func (s *UserAPITestSuite) TestHTTPGetAllUsersInvalidCursor() {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/users?cursor=not-a-cursor", nil)
+	s.router.ServeHTTP(res, req)
+	s.Equal(http.StatusBadRequest, res.Code, "Status must be 400:BadRequest")
+}
+
+func (s *UserAPITestSuite) TestHTTPGetAllUsersInvalidPage() {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/users?offset=-1", nil)
+	s.router.ServeHTTP(res, req)
+	s.Equal(http.StatusBadRequest, res.Code, "Status must be 400:BadRequest")
 }
 
 func (s *UserAPITestSuite) TestHTTPGetUsers() {
@@ -81,3 +151,31 @@ func (s *UserAPITestSuite) TestHTTPGetUsersWithEmptyData() {
 func TestUserAPITestSuite(t *testing.T) {
 	suite.Run(t, new(UserAPITestSuite))
 }
+
+func TestHTTPCreateUserHidesPassword(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}))
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "users" WHERE (username = $1 OR email = $2)`)).
+		WithArgs("utain", "utain@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(`INSERT INTO "users"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	router := gin.New()
+	router.Use(errors.GinError())
+	router.POST("/api/v1/users", v1.NewUserAPI(repository.NewGormUserRepository(gdb), auth.DefaultPolicy).CreateUser)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/users",
+		strings.NewReader(`{"username":"utain","email":"utain@example.com","password":"S3cretPassw0rd"}`))
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusCreated, res.Code, "Status must be 201:Created")
+	require.NotContains(t, res.Body.String(), "password")
+	require.NotContains(t, res.Body.String(), "S3cretPassw0rd")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Config of the access tokens
+type Config struct {
+	Issuer string
+	// TTL lifetime of an access token
+	TTL time.Duration
+	// SigningKey id of the key in Keys used to sign new tokens, every key of
+	// Keys is accepted to verify tokens so keys can be rotated
+	SigningKey string
+	Keys       map[string]KeyConfig
+	// Policy override the roles allowed per action of DefaultPolicy
+	Policy Policy
+}
+
+// KeyConfig of a signing key
+type KeyConfig struct {
+	// Algorithm available(HS256; HS384; HS512; RS256; ES256), default HS256
+	Algorithm string
+	// Secret of the hmac algorithms
+	Secret string
+	// PrivateKeyFile pem encoded private key of the rsa and ecdsa algorithms
+	PrivateKeyFile string
+	// PublicKeyFile pem encoded public key of the rsa and ecdsa algorithms,
+	// enough for keys only used to verify tokens
+	PublicKeyFile string
+}
+
+var (
+	ErrUndefinedSigningKey = errors.New("undefined signing key, auth.signingkey must name one of auth.keys")
+	ErrUndefinedAlgorithm  = errors.New("undefined signing algorithm, available(HS256; HS384; HS512; RS256; ES256)")
+	// ErrInvalidToken token is malformed, expired or not signed by a known key
+	ErrInvalidToken = errors.New("invalid access token")
+	// ErrPlaceholderSecret secret left to the placeholder shipped with the
+	// former default config, anyone could forge tokens with it
+	ErrPlaceholderSecret = errors.New("secret is a placeholder, set a random secret")
+)
+
+// PlaceholderSecret refused as the secret of a key
+const PlaceholderSecret = "change-me-in-production"
+
+// Principal authenticated user of a request, roles and permissions are those
+// of the user when the token was issued
+type Principal struct {
+	ID          string   `json:"id"`
+	Username    string   `json:"username"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// Claims of the access token
+type Claims struct {
+	Username    string   `json:"username"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type key struct {
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// Tokens issue and verify signed access tokens
+type Tokens struct {
+	cnf     Config
+	keys    map[string]key
+	methods []string
+}
+
+// New load the keys of cnf
+func New(cnf Config) (*Tokens, error) {
+	t := &Tokens{cnf: cnf, keys: map[string]key{}}
+	seen := map[string]bool{}
+	for id, keyCnf := range cnf.Keys {
+		k, err := loadKey(keyCnf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key %s: %w", id, err)
+		}
+		t.keys[id] = k
+		if alg := k.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			t.methods = append(t.methods, alg)
+		}
+	}
+	if signing, ok := t.keys[cnf.SigningKey]; !ok || signing.signKey == nil {
+		return nil, ErrUndefinedSigningKey
+	}
+	return t, nil
+}
+
+func loadKey(cnf KeyConfig) (key, error) {
+	switch cnf.Algorithm {
+	case "", "HS256", "HS384", "HS512":
+		if cnf.Secret == "" {
+			return key{}, errors.New("secret is required")
+		}
+		if cnf.Secret == PlaceholderSecret {
+			return key{}, ErrPlaceholderSecret
+		}
+		method := jwt.SigningMethodHS256
+		if cnf.Algorithm != "" {
+			method = jwt.GetSigningMethod(cnf.Algorithm).(*jwt.SigningMethodHMAC)
+		}
+		return key{method: method, signKey: []byte(cnf.Secret), verifyKey: []byte(cnf.Secret)}, nil
+	case "RS256":
+		k := key{method: jwt.SigningMethodRS256}
+		if cnf.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(cnf.PrivateKeyFile)
+			if err != nil {
+				return key{}, err
+			}
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return key{}, err
+			}
+			k.signKey, k.verifyKey = private, &private.PublicKey
+		}
+		if cnf.PublicKeyFile != "" {
+			pem, err := os.ReadFile(cnf.PublicKeyFile)
+			if err != nil {
+				return key{}, err
+			}
+			if k.verifyKey, err = jwt.ParseRSAPublicKeyFromPEM(pem); err != nil {
+				return key{}, err
+			}
+		}
+		if k.verifyKey == nil {
+			return key{}, errors.New("private or public key file is required")
+		}
+		return k, nil
+	case "ES256":
+		k := key{method: jwt.SigningMethodES256}
+		if cnf.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(cnf.PrivateKeyFile)
+			if err != nil {
+				return key{}, err
+			}
+			private, err := jwt.ParseECPrivateKeyFromPEM(pem)
+			if err != nil {
+				return key{}, err
+			}
+			k.signKey, k.verifyKey = private, &private.PublicKey
+		}
+		if cnf.PublicKeyFile != "" {
+			pem, err := os.ReadFile(cnf.PublicKeyFile)
+			if err != nil {
+				return key{}, err
+			}
+			if k.verifyKey, err = jwt.ParseECPublicKeyFromPEM(pem); err != nil {
+				return key{}, err
+			}
+		}
+		if k.verifyKey == nil {
+			return key{}, errors.New("private or public key file is required")
+		}
+		return k, nil
+	}
+	return key{}, ErrUndefinedAlgorithm
+}
+
+// Issue sign a new access token for p
+func (t *Tokens) Issue(p Principal) (token string, expiresAt time.Time, err error) {
+	now := time.Now()
+	expiresAt = now.Add(t.cnf.TTL)
+	signing := t.keys[t.cnf.SigningKey]
+	jwtToken := jwt.NewWithClaims(signing.method, Claims{
+		Username:    p.Username,
+		Roles:       p.Roles,
+		Permissions: p.Permissions,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    t.cnf.Issuer,
+			Subject:   p.ID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+	jwtToken.Header["kid"] = t.cnf.SigningKey
+	if token, err = jwtToken.SignedString(signing.signKey); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// Verify check the signature, issuer and expiry of token
+func (t *Tokens) Verify(token string) (*Principal, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		k, ok := t.keys[kid]
+		if !ok || k.method.Alg() != token.Method.Alg() {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return k.verifyKey, nil
+	}, jwt.WithValidMethods(t.methods), jwt.WithIssuer(t.cnf.Issuer))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+	if claims.ExpiresAt == nil || claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing expiry or subject", ErrInvalidToken)
+	}
+	return &Principal{
+		ID:          claims.Subject,
+		Username:    claims.Username,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+	}, nil
+}
+
+type ctxKeyPrincipal int
+
+const principalKey ctxKeyPrincipal = 0
+
+// WithPrincipal return a copy of ctx holding p
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// PrincipalFromContext return the authenticated user of ctx, nil when the
+// request is anonymous
+func PrincipalFromContext(ctx context.Context) *Principal {
+	if ctx == nil {
+		return nil
+	}
+	p, _ := ctx.Value(principalKey).(*Principal)
+	return p
+}
@@ -0,0 +1,110 @@
+package auth_test
+
+import (
+	"go-example/internal/auth"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type TokensTestSuite struct {
+	suite.Suite
+	cnf auth.Config
+}
+
+func (s *TokensTestSuite) SetupTest() {
+	s.cnf = auth.Config{
+		Issuer:     "test",
+		TTL:        time.Minute,
+		SigningKey: "current",
+		Keys: map[string]auth.KeyConfig{
+			"current":  {Algorithm: "HS256", Secret: "current-secret"},
+			"previous": {Algorithm: "HS512", Secret: "previous-secret"},
+		},
+	}
+}
+
+func (s *TokensTestSuite) tokens(cnf auth.Config) *auth.Tokens {
+	tokens, err := auth.New(cnf)
+	require.NoError(s.T(), err)
+	return tokens
+}
+
+func (s *TokensTestSuite) TestIssueAndVerify() {
+	tokens := s.tokens(s.cnf)
+	token, expiresAt, err := tokens.Issue(auth.Principal{ID: "1", Username: "utain"})
+	s.Require().NoError(err)
+	s.WithinDuration(time.Now().Add(time.Minute), expiresAt, time.Second)
+
+	principal, err := tokens.Verify(token)
+	s.Require().NoError(err)
+	s.Equal(&auth.Principal{ID: "1", Username: "utain"}, principal)
+}
+
+func (s *TokensTestSuite) TestVerifyRotatedKey() {
+	rotated := s.cnf
+	rotated.SigningKey = "previous"
+	token, _, err := s.tokens(rotated).Issue(auth.Principal{ID: "1"})
+	s.Require().NoError(err)
+
+	_, err = s.tokens(s.cnf).Verify(token)
+	s.NoError(err, "token signed by a previous key must stay valid")
+}
+
+func (s *TokensTestSuite) TestRejectInvalidTokens() {
+	tokens := s.tokens(s.cnf)
+
+	other := s.cnf
+	other.Issuer = "other"
+	token, _, _ := s.tokens(other).Issue(auth.Principal{ID: "1"})
+	_, err := tokens.Verify(token)
+	s.ErrorIs(err, auth.ErrInvalidToken, "issuer must match")
+
+	expired := s.cnf
+	expired.TTL = -time.Minute
+	token, _, _ = s.tokens(expired).Issue(auth.Principal{ID: "1"})
+	_, err = tokens.Verify(token)
+	s.ErrorIs(err, auth.ErrInvalidToken, "expired token must be rejected")
+
+	forged := s.cnf
+	forged.Keys = map[string]auth.KeyConfig{"current": {Secret: "forged"}}
+	token, _, _ = s.tokens(forged).Issue(auth.Principal{ID: "1"})
+	_, err = tokens.Verify(token)
+	s.ErrorIs(err, auth.ErrInvalidToken, "signature must match")
+}
+
+func (s *TokensTestSuite) TestUndefinedSigningKey() {
+	s.cnf.SigningKey = "missing"
+	_, err := auth.New(s.cnf)
+	s.ErrorIs(err, auth.ErrUndefinedSigningKey)
+}
+
+func (s *TokensTestSuite) TestRejectUnsafeSecrets() {
+	s.cnf.Keys["previous"] = auth.KeyConfig{Secret: ""}
+	_, err := auth.New(s.cnf)
+	s.ErrorContains(err, "secret is required")
+
+	s.cnf.Keys["previous"] = auth.KeyConfig{Secret: auth.PlaceholderSecret}
+	_, err = auth.New(s.cnf)
+	s.ErrorIs(err, auth.ErrPlaceholderSecret)
+}
+
+func TestTokensTestSuite(t *testing.T) {
+	suite.Run(t, new(TokensTestSuite))
+}
+
+func TestPolicyAllowed(t *testing.T) {
+	policy := auth.NewPolicy(auth.Policy{"users:delete": {"manager"}})
+
+	user := &auth.Principal{ID: "1"}
+	require.False(t, policy.Allowed(user, auth.ActionDeleteProduct))
+	require.True(t, policy.Allowed(user, "products:create"), "action without policy is allowed")
+	require.False(t, policy.Allowed(nil, "products:create"), "anonymous is never allowed")
+
+	require.True(t, policy.Allowed(&auth.Principal{Roles: []string{"admin"}}, auth.ActionDeleteProduct))
+	require.True(t, policy.Allowed(&auth.Principal{Roles: []string{"manager"}}, "users:delete"))
+	require.True(t, policy.Allowed(&auth.Principal{Permissions: []string{auth.ActionDeleteProduct}}, auth.ActionDeleteProduct))
+	require.True(t, policy.Allowed(&auth.Principal{Permissions: []string{auth.WildcardPermission}}, "users:delete"))
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"go-example/internal/errors"
+	"go-example/internal/log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const bearerPrefix = "Bearer "
+
+// GinRequired middleware reject requests without a valid bearer access token
+// and store the authenticated user in the request context
+func GinRequired(tokens *Tokens) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if len(header) < len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+			c.Header("WWW-Authenticate", `Bearer`)
+			c.Error(errors.NewError(http.StatusUnauthorized, "missing access token"))
+			c.Abort()
+			return
+		}
+		principal, err := tokens.Verify(header[len(bearerPrefix):])
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.Error(errors.NewError(http.StatusUnauthorized, ErrInvalidToken.Error()))
+			c.Abort()
+			return
+		}
+		c.Request = c.Request.WithContext(WithPrincipal(c.Request.Context(), principal))
+		log.WithFields(c.Request, log.String("user-id", principal.ID))
+		c.Next()
+	}
+}
+
+// GinAuthorize middleware reject requests of users the policy does not allow
+// to perform action, it must run after GinRequired
+func GinAuthorize(policy Policy, action string) gin.HandlerFunc {
+	return authorize(policy, action, "")
+}
+
+// GinAuthorizeOwner middleware is GinAuthorize, except that users whose ID is
+// the path parameter param are always allowed
+func GinAuthorizeOwner(policy Policy, action, param string) gin.HandlerFunc {
+	return authorize(policy, action, param)
+}
+
+func authorize(policy Policy, action, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := PrincipalFromContext(c.Request.Context())
+		if principal == nil {
+			c.Error(errors.NewError(http.StatusUnauthorized, "missing access token"))
+			c.Abort()
+			return
+		}
+		owner := param != "" && principal.ID == c.Param(param)
+		if !owner && !policy.Allowed(principal, action) {
+			c.Error(errors.NewError(http.StatusForbidden, "permission denied"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
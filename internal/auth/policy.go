@@ -0,0 +1,69 @@
+package auth
+
+// Policy roles allowed to perform an action, actions without policy are
+// allowed for every authenticated user
+type Policy map[string][]string
+
+// WildcardPermission grant every action
+const WildcardPermission = "*"
+
+// Actions guarded by the default policy
+const (
+	ActionCreateUser = "users:create"
+	// ActionUpdateUser update another user, users may always update
+	// themselves
+	ActionUpdateUser = "users:update"
+	// ActionUpdateCredentials change the password of another user
+	ActionUpdateCredentials = "users:credentials"
+	ActionDeleteUser        = "users:delete"
+	// ActionWriteProduct create, replace or patch a product
+	ActionWriteProduct  = "products:write"
+	ActionDeleteProduct = "products:delete"
+)
+
+// DefaultPolicy applied when the configuration does not override an action
+var DefaultPolicy = Policy{
+	ActionCreateUser:        {"admin"},
+	ActionUpdateUser:        {"admin"},
+	ActionUpdateCredentials: {"admin"},
+	ActionDeleteUser:        {"admin"},
+	ActionWriteProduct:      {"admin"},
+	ActionDeleteProduct:     {"admin"},
+}
+
+// NewPolicy merge the configured policy into DefaultPolicy
+func NewPolicy(cnf Policy) Policy {
+	policy := Policy{}
+	for action, roles := range DefaultPolicy {
+		policy[action] = roles
+	}
+	for action, roles := range cnf {
+		policy[action] = roles
+	}
+	return policy
+}
+
+// Allowed report whether p may perform action, either through one of the
+// roles of the policy or through a permission granted by its roles
+func (policy Policy) Allowed(p *Principal, action string) bool {
+	if p == nil {
+		return false
+	}
+	for _, permission := range p.Permissions {
+		if permission == action || permission == WildcardPermission {
+			return true
+		}
+	}
+	roles, ok := policy[action]
+	if !ok {
+		return true
+	}
+	for _, allowed := range roles {
+		for _, role := range p.Roles {
+			if role == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -3,13 +3,20 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"go-example/internal/admin"
+	"go-example/internal/auth"
+	"go-example/internal/database"
+	"go-example/internal/health"
 	"go-example/internal/log"
 	"go-example/internal/metric"
+	"go-example/internal/tlsconfig"
 	"go-example/internal/trace"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
-	"go.uber.org/zap"
 )
 
 var viperInstance = viper.New()
@@ -17,6 +24,19 @@ var Default Config
 
 func init() {
 	log.Debug("INIT CONFIG")
+	viperInstance.SetDefault("server.shutdowntimeout", 10*time.Second)
+	viperInstance.SetDefault("server.requesttimeout", 30*time.Second)
+	viperInstance.SetDefault("auth.ttl", 15*time.Minute)
+	viperInstance.SetDefault("database.pool.maxidle", 10)
+	viperInstance.SetDefault("database.pool.connmaxlifetime", 30*time.Minute)
+	viperInstance.SetDefault("database.pool.connmaxidletime", 5*time.Minute)
+	viperInstance.SetDefault("database.pool.connecttimeout", 5*time.Second)
+	viperInstance.SetDefault("health.timeout", 2*time.Second)
+	viperInstance.SetDefault("health.cachettl", 5*time.Second)
+	viperInstance.SetDefault("otel.trace.proto", "none")
+	viperInstance.SetDefault("otel.metric.proto", "none")
+	viperInstance.SetDefault("otel.log.export.proto", "none")
+	viperInstance.SetDefault("otel.log.export.insecure", false)
 }
 
 // Config struct
@@ -25,17 +45,20 @@ type Config struct {
 		ServiceName string
 	}
 	Server struct {
-		Port uint
-		Host string
+		Port  uint
+		Host  string
+		Admin admin.Config
+		// ShutdownTimeout bound the time to drain in-flight requests on shutdown
+		ShutdownTimeout time.Duration
+		// RequestTimeout deadline of the context of api requests, 0 disables it
+		RequestTimeout time.Duration
+		TLS            tlsconfig.Config
 	}
-	Database struct {
-		URL  string
-		Pool struct {
-			Max uint
-		}
-	}
-	Otel struct {
-		Log    zap.Config
+	Auth     auth.Config
+	Database database.Config
+	Health   health.Config
+	Otel     struct {
+		Log    log.Config
 		Trace  trace.Config
 		Metric metric.Config
 	}
@@ -48,7 +71,11 @@ func (d Config) String() string {
 
 // Parse get all config support in app
 func Parse() Config {
-	if err := viperInstance.Unmarshal(&Default, viper.DecodeHook(mapstructure.TextUnmarshallerHookFunc())); err != nil {
+	if err := viperInstance.Unmarshal(&Default, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		mapstructure.TextUnmarshallerHookFunc(),
+	))); err != nil {
 		log.Fatal(
 			fmt.Sprintf("Fail to read configuration: %s", err.Error()))
 	}
@@ -59,3 +86,43 @@ func Parse() Config {
 func Viper() *viper.Viper {
 	return viperInstance
 }
+
+// secretKeys settings with these words in their key are never exposed
+var secretKeys = []string{"password", "secret", "token", "privatekey"}
+
+// Masked return the effective settings with secrets redacted
+func Masked() map[string]interface{} {
+	return maskSettings(viperInstance.AllSettings())
+}
+
+func maskSettings(settings map[string]interface{}) map[string]interface{} {
+	masked := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		masked[key] = maskValue(key, value)
+	}
+	return masked
+}
+
+func maskValue(key string, value interface{}) interface{} {
+	for _, secret := range secretKeys {
+		if strings.Contains(strings.ToLower(key), secret) {
+			return "xxxxx"
+		}
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return maskSettings(v)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = maskValue(key, item)
+		}
+		return items
+	case string:
+		// credentials embedded in connection urls
+		if u, err := url.Parse(v); err == nil && u.User != nil {
+			return u.Redacted()
+		}
+	}
+	return value
+}
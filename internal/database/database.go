@@ -0,0 +1,156 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Config of the database connection, every replica use the same pool
+// settings as the primary
+type Config struct {
+	URL      string
+	Replicas ReplicasConfig
+	Pool     PoolConfig
+}
+
+// PoolConfig of the underlying sql.DB connection pool, zero values keep the
+// database/sql and server defaults
+type PoolConfig struct {
+	// Max former name of MaxOpen, used when MaxOpen is not set
+	Max             uint
+	MaxOpen         uint
+	MaxIdle         uint
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// ConnectTimeout of a new connection, rounded up to the second
+	ConnectTimeout time.Duration
+	// StatementTimeout server side limit of every statement of the session,
+	// zero disables it
+	StatementTimeout time.Duration
+}
+
+// Open connect to the database and apply the pool settings. Every statement
+// is traced by TracingPlugin. With replicas the queries of ReadOnly contexts
+// are routed to them
+func Open(cnf Config) (*gorm.DB, error) {
+	db, err := open(cnf.URL, cnf.Pool)
+	if err != nil {
+		return nil, err
+	}
+	if err := useTracing(db); err != nil {
+		Close(db)
+		return nil, err
+	}
+	if len(cnf.Replicas.URLs) == 0 {
+		return db, nil
+	}
+
+	primary, _ := db.DB()
+	replicas := make([]*sql.DB, 0, len(cnf.Replicas.URLs))
+	closeAll := func() {
+		for _, replica := range replicas {
+			replica.Close()
+		}
+		primary.Close()
+	}
+	for i, replicaURL := range cnf.Replicas.URLs {
+		replica, err := open(replicaURL, cnf.Pool)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("replica %d: %w", i, err)
+		}
+		sqlDB, _ := replica.DB()
+		replicas = append(replicas, sqlDB)
+	}
+	router, err := NewRouter(primary, replicas, cnf.Replicas)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	db.ConnPool = router
+	db.Statement.ConnPool = router
+	return db, nil
+}
+
+// open a connection pool with the pool settings
+func open(dsnURL string, pool PoolConfig) (*gorm.DB, error) {
+	dsn, err := DSN(Config{URL: dsnURL, Pool: pool})
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(postgres.Open(dsn))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect database: %w", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection pool: %w", err)
+	}
+	maxOpen := pool.MaxOpen
+	if maxOpen == 0 {
+		maxOpen = pool.Max
+	}
+	sqlDB.SetMaxOpenConns(int(maxOpen))
+	if pool.MaxIdle != 0 {
+		sqlDB.SetMaxIdleConns(int(pool.MaxIdle))
+	}
+	sqlDB.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	return db, nil
+}
+
+// DSN add the connect and statement timeouts of cnf to the connection
+// string, either a postgres:// url or key=value settings
+func DSN(cnf Config) (string, error) {
+	params := map[string]string{}
+	if timeout := cnf.Pool.ConnectTimeout; timeout > 0 {
+		params["connect_timeout"] = strconv.Itoa(int(math.Ceil(timeout.Seconds())))
+	}
+	if timeout := cnf.Pool.StatementTimeout; timeout > 0 {
+		params["statement_timeout"] = strconv.FormatInt(timeout.Milliseconds(), 10)
+	}
+	if len(params) == 0 {
+		return cnf.URL, nil
+	}
+
+	if strings.Contains(cnf.URL, "://") {
+		u, err := url.Parse(cnf.URL)
+		if err != nil {
+			return "", fmt.Errorf("invalid database url: %w", err)
+		}
+		query := u.Query()
+		for key, value := range params {
+			query.Set(key, value)
+		}
+		u.RawQuery = query.Encode()
+		return u.String(), nil
+	}
+	dsn := cnf.URL
+	for _, key := range []string{"connect_timeout", "statement_timeout"} {
+		if value, ok := params[key]; ok {
+			dsn += " " + key + "=" + value
+		}
+	}
+	return strings.TrimSpace(dsn), nil
+}
+
+// Close release all connections of the pool and of the replicas
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database connection pool: %w", err)
+	}
+	if router, ok := db.ConnPool.(*Router); ok {
+		return errors.Join(router.Close(), sqlDB.Close())
+	}
+	return sqlDB.Close()
+}
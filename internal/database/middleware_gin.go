@@ -0,0 +1,22 @@
+package database
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadYourWritesHeader request header forcing the reads of the request to
+// the primary, e.g. right after a write
+const ReadYourWritesHeader = "X-Read-Your-Writes"
+
+// GinReadYourWrites mark the context of requests with a true
+// ReadYourWritesHeader with ReadYourWrites
+func GinReadYourWrites() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if force, _ := strconv.ParseBool(ctx.GetHeader(ReadYourWritesHeader)); force {
+			ctx.Request = ctx.Request.WithContext(ReadYourWrites(ctx.Request.Context()))
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,223 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-example/internal/log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Policy selection of the replica serving a read
+type Policy string
+
+const (
+	// RoundRobin rotate reads over the healthy replicas
+	RoundRobin Policy = "round-robin"
+	// LeastConn send reads to the healthy replica with the fewest connections
+	// in use
+	LeastConn Policy = "least-conn"
+
+	defaultHealthInterval = 5 * time.Second
+)
+
+// ErrUndefinedPolicy replica policy is not supported
+var ErrUndefinedPolicy = errors.New("undefined replica policy, available(round-robin; least-conn)")
+
+// ReplicasConfig of the read replicas, reads fall back to the primary when
+// every replica is ejected
+type ReplicasConfig struct {
+	URLs   []string
+	Policy Policy
+	// HealthInterval between pings of the replicas, a failed ping eject the
+	// replica until a ping succeeds
+	HealthInterval time.Duration
+}
+
+type ctxKey int
+
+const (
+	readOnlyKey ctxKey = iota
+	readYourWritesKey
+)
+
+// ReadOnly mark the queries run with ctx as safe to serve from a replica
+func ReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey, true)
+}
+
+// ReadYourWrites send every query run with ctx to the primary, even the
+// ReadOnly ones, so they observe the writes done before
+func ReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readYourWritesKey, true)
+}
+
+// useReplica report whether the queries of ctx may go to a replica
+func useReplica(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	readOnly, _ := ctx.Value(readOnlyKey).(bool)
+	primary, _ := ctx.Value(readYourWritesKey).(bool)
+	return readOnly && !primary
+}
+
+type replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// Router gorm connection pool sending the queries of ReadOnly contexts to
+// the replicas and everything else, statements and transactions included,
+// to the primary
+type Router struct {
+	primary  *sql.DB
+	replicas []*replica
+	policy   Policy
+	next     atomic.Uint64
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// NewRouter route reads over replicas and check their health every interval
+// of cnf until Close
+func NewRouter(primary *sql.DB, replicas []*sql.DB, cnf ReplicasConfig) (*Router, error) {
+	switch cnf.Policy {
+	case "":
+		cnf.Policy = RoundRobin
+	case RoundRobin, LeastConn:
+	default:
+		return nil, ErrUndefinedPolicy
+	}
+	if cnf.HealthInterval <= 0 {
+		cnf.HealthInterval = defaultHealthInterval
+	}
+	r := &Router{primary: primary, policy: cnf.Policy, stop: make(chan struct{})}
+	for i, db := range replicas {
+		rep := &replica{name: fmt.Sprintf("replica-%d", i), db: db}
+		rep.healthy.Store(true)
+		r.replicas = append(r.replicas, rep)
+	}
+	r.done.Add(1)
+	go r.checkHealth(cnf.HealthInterval)
+	return r, nil
+}
+
+// Replicas connection pools of the replicas by name
+func (r *Router) Replicas() map[string]*sql.DB {
+	replicas := map[string]*sql.DB{}
+	for _, rep := range r.replicas {
+		replicas[rep.name] = rep.db
+	}
+	return replicas
+}
+
+// GetDBConn return the primary pool, used by gorm.DB.DB
+func (r *Router) GetDBConn() (*sql.DB, error) {
+	return r.primary, nil
+}
+
+// PrepareContext prepare on the primary
+func (r *Router) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return r.primary.PrepareContext(ctx, query)
+}
+
+// ExecContext execute on the primary
+func (r *Router) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.primary.ExecContext(ctx, query, args...)
+}
+
+// QueryContext query a replica when ctx is ReadOnly
+func (r *Router) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.pool(ctx).QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext query a replica when ctx is ReadOnly
+func (r *Router) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.pool(ctx).QueryRowContext(ctx, query, args...)
+}
+
+// BeginTx start transactions on the primary
+func (r *Router) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return r.primary.BeginTx(ctx, opts)
+}
+
+// Close stop the health checks and close the replicas, the primary is closed
+// by the owner of the gorm.DB
+func (r *Router) Close() error {
+	close(r.stop)
+	r.done.Wait()
+	var errs []error
+	for _, rep := range r.replicas {
+		if err := rep.db.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", rep.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Router) pool(ctx context.Context) *sql.DB {
+	if !useReplica(ctx) {
+		return r.primary
+	}
+	if rep := r.pick(); rep != nil {
+		return rep.db
+	}
+	return r.primary
+}
+
+// pick a healthy replica by policy, nil when every replica is ejected
+func (r *Router) pick() *replica {
+	healthy := make([]*replica, 0, len(r.replicas))
+	for _, rep := range r.replicas {
+		if rep.healthy.Load() {
+			healthy = append(healthy, rep)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+	if r.policy == LeastConn {
+		least := healthy[0]
+		inUse := least.db.Stats().InUse
+		for _, rep := range healthy[1:] {
+			if n := rep.db.Stats().InUse; n < inUse {
+				least, inUse = rep, n
+			}
+		}
+		return least
+	}
+	return healthy[(r.next.Add(1)-1)%uint64(len(healthy))]
+}
+
+func (r *Router) checkHealth(interval time.Duration) {
+	defer r.done.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+		for _, rep := range r.replicas {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := rep.db.PingContext(ctx)
+			cancel()
+			healthy := err == nil
+			if rep.healthy.Swap(healthy) == healthy {
+				continue
+			}
+			if healthy {
+				log.Info("Database " + rep.name + " is back in rotation")
+			} else {
+				log.Warn("Database " + rep.name + " ejected from rotation: " + err.Error())
+			}
+		}
+	}
+}
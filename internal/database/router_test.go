@@ -0,0 +1,123 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-example/internal/database"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type RouterTestSuite struct {
+	suite.Suite
+	db       *gorm.DB
+	primary  sqlmock.Sqlmock
+	replicas []sqlmock.Sqlmock
+	router   *database.Router
+}
+
+func (s *RouterTestSuite) SetupTest() {
+	primary, primaryMock, err := sqlmock.New()
+	require.NoError(s.T(), err)
+	s.primary = primaryMock
+	s.replicas = nil
+	replicas := []*sql.DB{}
+	for i := 0; i < 2; i++ {
+		replica, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		require.NoError(s.T(), err)
+		replicas = append(replicas, replica)
+		s.replicas = append(s.replicas, mock)
+	}
+	s.router, err = database.NewRouter(primary, replicas, database.ReplicasConfig{HealthInterval: time.Hour})
+	require.NoError(s.T(), err)
+	s.db, err = gorm.Open(postgres.New(postgres.Config{Conn: primary}))
+	require.NoError(s.T(), err)
+	s.db.ConnPool = s.router
+	s.db.Statement.ConnPool = s.router
+}
+
+func (s *RouterTestSuite) TearDownTest() {
+	s.router.Close()
+	s.NoError(s.primary.ExpectationsWereMet())
+	for _, mock := range s.replicas {
+		s.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func (s *RouterTestSuite) count(ctx context.Context) error {
+	var n int64
+	return s.db.WithContext(ctx).Raw("SELECT count(*) FROM users").Scan(&n).Error
+}
+
+func countRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"count"}).AddRow(1)
+}
+
+func (s *RouterTestSuite) TestReadOnlyRoundRobin() {
+	s.replicas[0].ExpectQuery("SELECT count").WillReturnRows(countRows())
+	s.replicas[1].ExpectQuery("SELECT count").WillReturnRows(countRows())
+	s.replicas[0].ExpectQuery("SELECT count").WillReturnRows(countRows())
+
+	ctx := database.ReadOnly(context.Background())
+	for i := 0; i < 3; i++ {
+		s.NoError(s.count(ctx))
+	}
+}
+
+func (s *RouterTestSuite) TestPrimaryByDefault() {
+	s.primary.ExpectQuery("SELECT count").WillReturnRows(countRows())
+	s.NoError(s.count(context.Background()))
+}
+
+func (s *RouterTestSuite) TestReadYourWrites() {
+	s.primary.ExpectQuery("SELECT count").WillReturnRows(countRows())
+	s.NoError(s.count(database.ReadYourWrites(database.ReadOnly(context.Background()))))
+}
+
+func (s *RouterTestSuite) TestTransactionOnPrimary() {
+	s.primary.ExpectBegin()
+	s.primary.ExpectQuery("SELECT count").WillReturnRows(countRows())
+	s.primary.ExpectCommit()
+
+	err := s.db.WithContext(database.ReadOnly(context.Background())).Transaction(func(tx *gorm.DB) error {
+		var n int64
+		return tx.Raw("SELECT count(*) FROM users").Scan(&n).Error
+	})
+	s.NoError(err)
+}
+
+func (s *RouterTestSuite) TestEjectUnhealthyReplica() {
+	primary, primaryMock, err := sqlmock.New()
+	s.Require().NoError(err)
+	replica, replicaMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	s.Require().NoError(err)
+	replicaMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	router, err := database.NewRouter(primary, []*sql.DB{replica}, database.ReplicasConfig{HealthInterval: 10 * time.Millisecond})
+	s.Require().NoError(err)
+	defer router.Close()
+
+	s.Eventually(func() bool { return replicaMock.ExpectationsWereMet() == nil }, time.Second, 5*time.Millisecond)
+	// let the failed ping be recorded before routing
+	time.Sleep(20 * time.Millisecond)
+	primaryMock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+	rows, err := router.QueryContext(database.ReadOnly(context.Background()), "SELECT 1")
+	s.Require().NoError(err)
+	rows.Close()
+	s.NoError(primaryMock.ExpectationsWereMet())
+}
+
+func (s *RouterTestSuite) TestUndefinedPolicy() {
+	_, err := database.NewRouter(nil, nil, database.ReplicasConfig{Policy: "random"})
+	s.ErrorIs(err, database.ErrUndefinedPolicy)
+}
+
+func TestRouterTestSuite(t *testing.T) {
+	suite.Run(t, new(RouterTestSuite))
+}
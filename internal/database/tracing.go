@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	internalTrace "go-example/internal/trace"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// instrumentationName name of the tracer and the meter of this package
+const instrumentationName = "go-example/internal/database"
+
+const (
+	spanKey   = "otel:span"
+	startKey  = "otel:start"
+	parentKey = "otel:parent"
+)
+
+// rowsAffectedKey number of rows returned or changed by the statement
+var rowsAffectedKey = attribute.Key("db.rows_affected")
+
+// TracingPlugin emit a client span for every statement run by gorm and record
+// its duration in the db.client.operation.duration histogram. Statements are
+// reported with their placeholders, never with the values
+type TracingPlugin struct {
+	tracer   *internalTrace.Tracer
+	duration instrument.Float64Histogram
+}
+
+// NewTracingPlugin trace through internal/trace and measure through the
+// global meter provider
+func NewTracingPlugin() (*TracingPlugin, error) {
+	duration, err := global.MeterProvider().Meter(instrumentationName).Float64Histogram(
+		"db.client.operation.duration",
+		instrument.WithUnit("ms"),
+		instrument.WithDescription("Duration of database client operations"))
+	if err != nil {
+		return nil, err
+	}
+	return &TracingPlugin{
+		tracer:   internalTrace.GetTracer(instrumentationName),
+		duration: duration,
+	}, nil
+}
+
+// useTracing register a TracingPlugin on db
+func useTracing(db *gorm.DB) error {
+	tracing, err := NewTracingPlugin()
+	if err != nil {
+		return err
+	}
+	if err := db.Use(tracing); err != nil {
+		return fmt.Errorf("failed to register tracing: %w", err)
+	}
+	return nil
+}
+
+// Name of the plugin for gorm.DB.Use
+func (p *TracingPlugin) Name() string {
+	return "otel:tracing"
+}
+
+// Initialize register the callbacks around every processor of db, the span
+// starts before the transaction of the statement and ends after its hooks
+func (p *TracingPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().Before("*").Register("otel:before_create", p.before),
+		callbacks.Create().After("*").Register("otel:after_create", p.after),
+		callbacks.Query().Before("*").Register("otel:before_query", p.before),
+		callbacks.Query().After("*").Register("otel:after_query", p.after),
+		callbacks.Update().Before("*").Register("otel:before_update", p.before),
+		callbacks.Update().After("*").Register("otel:after_update", p.after),
+		callbacks.Delete().Before("*").Register("otel:before_delete", p.before),
+		callbacks.Delete().After("*").Register("otel:after_delete", p.after),
+		callbacks.Row().Before("*").Register("otel:before_row", p.before),
+		callbacks.Row().After("*").Register("otel:after_row", p.after),
+		callbacks.Raw().Before("*").Register("otel:before_raw", p.before),
+		callbacks.Raw().After("*").Register("otel:after_raw", p.after),
+	)
+}
+
+// before start the span, the statement runs in its context so nested
+// statements such as preloads become its children
+func (p *TracingPlugin) before(db *gorm.DB) {
+	if db.Statement.Context == nil {
+		return
+	}
+	parent := db.Statement.Context
+	ctx, span := p.tracer.Start(parent, "db",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL))
+	db.Statement.Context = ctx
+	db.InstanceSet(parentKey, parent)
+	db.InstanceSet(spanKey, span)
+	db.InstanceSet(startKey, time.Now())
+}
+
+// after name the span by the operation and the table of the statement, known
+// only once the sql is built, then end it and record the duration
+func (p *TracingPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	start, _ := db.InstanceGet(startKey)
+	elapsed := time.Since(start.(time.Time))
+
+	statement := db.Statement.SQL.String()
+	operation := operationOf(statement)
+	attrs := []attribute.KeyValue{semconv.DBSystemPostgreSQL}
+	if operation != "" {
+		attrs = append(attrs, semconv.DBOperation(operation))
+	}
+	if db.Statement.Table != "" {
+		attrs = append(attrs, semconv.DBSQLTable(db.Statement.Table))
+	}
+
+	span.SetName(spanName(operation, db.Statement.Table))
+	span.SetAttributes(attrs...)
+	span.SetAttributes(semconv.DBStatement(statement), rowsAffectedKey.Int64(db.Statement.RowsAffected))
+	// a missing row is an answer, not a failure of the database
+	if err := db.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+	p.duration.Record(db.Statement.Context, float64(elapsed.Microseconds())/1000, attrs...)
+
+	// a statement reused by a following query, e.g. a count then a find,
+	// must not nest the next span under this one
+	if parent, ok := db.InstanceGet(parentKey); ok {
+		db.Statement.Context = parent.(context.Context)
+	}
+}
+
+// operationOf return the sql command of statement, e.g. SELECT
+func operationOf(statement string) string {
+	fields := strings.Fields(statement)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToUpper(fields[0])
+}
+
+// spanName follow the "<db.operation> <db.sql.table>" convention
+func spanName(operation, table string) string {
+	switch {
+	case operation == "":
+		return "db"
+	case table == "":
+		return operation
+	}
+	return operation + " " + table
+}
@@ -0,0 +1,85 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"go-example/internal/database"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric/global"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type item struct {
+	ID   string
+	Name string
+}
+
+func attrs(kvs []attribute.KeyValue) map[attribute.Key]attribute.Value {
+	m := map[attribute.Key]attribute.Value{}
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestTracingPlugin(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	reader := sdkmetric.NewManualReader()
+	global.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}))
+	require.NoError(t, err)
+	plugin, err := database.NewTracingPlugin()
+	require.NoError(t, err)
+	require.NoError(t, db.Use(plugin))
+
+	mock.ExpectQuery(`SELECT \* FROM "items"`).
+		WithArgs("secret").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("1", "secret"))
+	mock.ExpectExec(`DELETE FROM "items"`).
+		WillReturnError(errors.New("boom"))
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	require.NoError(t, db.WithContext(ctx).Where("name = ?", "secret").Find(&[]item{}).Error)
+	require.Error(t, db.WithContext(ctx).Session(&gorm.Session{SkipDefaultTransaction: true}).
+		Delete(&item{ID: "1"}).Error)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	query, del := spans[0], spans[1]
+	require.Equal(t, "SELECT items", query.Name())
+	require.Equal(t, parent.SpanContext().SpanID(), query.Parent().SpanID())
+	a := attrs(query.Attributes())
+	require.Equal(t, "postgresql", a["db.system"].AsString())
+	require.Equal(t, "items", a["db.sql.table"].AsString())
+	require.Equal(t, int64(1), a["db.rows_affected"].AsInt64())
+	require.Contains(t, a["db.statement"].AsString(), "name = $1")
+	require.NotContains(t, a["db.statement"].AsString(), "secret")
+	require.Equal(t, codes.Unset, query.Status().Code)
+
+	require.Equal(t, "DELETE items", del.Name())
+	require.Equal(t, codes.Error, del.Status().Code)
+	require.Equal(t, "boom", del.Status().Description)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Equal(t, "db.client.operation.duration", rm.ScopeMetrics[0].Metrics[0].Name)
+	histogram := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Histogram)
+	require.Len(t, histogram.DataPoints, 2, "one series per operation")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,7 +1,104 @@
 package dto
 
+const (
+	// DefaultLimit page size when the request does not set one
+	DefaultLimit = 20
+	// MaxLimit largest page size served
+	MaxLimit = 100
+)
+
+// Pageable page of a listing, Search filter the listing by text. A non nil
+// Cursor select keyset pagination instead of Offset, an empty cursor is the
+// first page
 type Pageable struct {
-	Offset int
-	Limit  int
-	Search string
+	Offset int     `form:"offset" binding:"min=0"`
+	Limit  int     `form:"limit" binding:"min=0"`
+	Search string  `form:"q"`
+	Cursor *string `form:"cursor"`
+}
+
+// Normalize apply the default limit and cap it to MaxLimit
+func (p *Pageable) Normalize() {
+	if p.Limit <= 0 {
+		p.Limit = DefaultLimit
+	}
+	if p.Limit > MaxLimit {
+		p.Limit = MaxLimit
+	}
+	if p.Offset < 0 {
+		p.Offset = 0
+	}
+}
+
+// SearchRequest full-text search of a listing, with Prefix every word of
+// Query also matches longer words
+type SearchRequest struct {
+	Query  string `form:"q"`
+	Prefix bool   `form:"prefix"`
+	Offset int    `form:"offset" binding:"min=0"`
+	Limit  int    `form:"limit" binding:"min=0"`
+}
+
+// Pageable page of the search results
+func (s SearchRequest) Pageable() Pageable {
+	pageable := Pageable{Offset: s.Offset, Limit: s.Limit}
+	pageable.Normalize()
+	return pageable
 }
+
+// ProductPropsRequest key/value property of a product
+type ProductPropsRequest struct {
+	Key   string `json:"key" binding:"required"`
+	Value string `json:"value"`
+} // @name ProductPropsRequest
+
+// ProductRequest body to create or replace a product, Props replace all
+// properties of the product
+type ProductRequest struct {
+	Name  string                `json:"name" binding:"required"`
+	Code  string                `json:"code" binding:"required"`
+	Price uint                  `json:"price"`
+	Attr  map[string]string     `json:"attr"`
+	Props []ProductPropsRequest `json:"props" binding:"dive"`
+} // @name ProductRequest
+
+// PatchProductPropsRequest change of one product property, a null value
+// removes the property
+type PatchProductPropsRequest struct {
+	Key   string  `json:"key" binding:"required"`
+	Value *string `json:"value"`
+} // @name PatchProductPropsRequest
+
+// PatchProductRequest partial update of a product, omitted fields are left
+// unchanged and Props are merged by key into the existing properties
+type PatchProductRequest struct {
+	Name  *string                    `json:"name" binding:"omitempty,min=1"`
+	Code  *string                    `json:"code" binding:"omitempty,min=1"`
+	Price *uint                      `json:"price"`
+	Attr  map[string]string          `json:"attr"`
+	Props []PatchProductPropsRequest `json:"props" binding:"dive"`
+} // @name PatchProductRequest
+
+// CreateUserRequest body to create a user
+type CreateUserRequest struct {
+	Username  string `json:"username" binding:"required"`
+	Email     string `json:"email" binding:"required,email"`
+	Password  string `json:"password" binding:"required,min=8,max=72"`
+	Firstname string `json:"firstname"`
+	Lastname  string `json:"lastname"`
+} // @name CreateUserRequest
+
+// UpdateUserRequest partial update of a user, omitted fields are left
+// unchanged
+type UpdateUserRequest struct {
+	Email     *string `json:"email" binding:"omitempty,email"`
+	Password  *string `json:"password" binding:"omitempty,min=8,max=72"`
+	Firstname *string `json:"firstname"`
+	Lastname  *string `json:"lastname"`
+} // @name UpdateUserRequest
+
+// LoginRequest credentials of a user
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+} // @name LoginRequest
@@ -2,8 +2,36 @@ package dto
 
 type DataReply struct {
 	Data interface{} `json:"data"`
+	Meta *Meta       `json:"meta,omitempty"`
 } // @name Response
 
+// Meta pagination of a listing, Next and Prev are links to the adjacent pages.
+// Total and Offset are only set with offset pagination, NextCursor only with
+// cursor pagination
+type Meta struct {
+	Total      *int64 `json:"total,omitempty"`
+	Offset     *int   `json:"offset,omitempty"`
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Next       string `json:"next,omitempty"`
+	Prev       string `json:"prev,omitempty"`
+} // @name Meta
+
+// Page result of a paginated query, Total is only counted with offset
+// pagination
+type Page struct {
+	Total      *int64
+	NextCursor string
+}
+
+// TokenReply access token issued on login
+type TokenReply struct {
+	AccessToken string `json:"accessToken"`
+	TokenType   string `json:"tokenType"`
+	// ExpiresIn lifetime of the token in seconds
+	ExpiresIn int64 `json:"expiresIn"`
+} // @name TokenReply
+
 type ErrorMessage struct {
 	Message string `json:"message"`
 } // @name ErrorMessage
@@ -1,6 +1,8 @@
 package entities
 
 import (
+	"crypto/rand"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -16,3 +18,26 @@ type Model struct {
 	UpdatedAt time.Time      `json:"updatedAt"`
 	DeletedAt gorm.DeletedAt `json:"-" sql:"index"`
 }
+
+// BeforeCreate generate the ID of new records
+func (m *Model) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		id, err := NewID()
+		if err != nil {
+			return err
+		}
+		m.ID = id
+	}
+	return nil
+}
+
+// NewID generate a random (version 4) uuid
+func NewID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate id: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
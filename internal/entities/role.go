@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// Role model, a user is granted the permissions of all its roles
+type Role struct {
+	Model
+	Name        string          `json:"name" gorm:"uniqueIndex"`
+	Permissions PermissionsType `json:"permissions" gorm:"type:text"`
+} //@name Role
+
+// PermissionsType list of actions granted to a role, "*" grants every action
+type PermissionsType []string
+
+// Value convert the permissions to json-string for save to database.
+func (p PermissionsType) Value() (driver.Value, error) {
+	permissions, err := json.Marshal(p)
+	return string(permissions), err
+}
+
+// Scan convert the json-string from database to permissions.
+func (p *PermissionsType) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	case []byte:
+		return json.Unmarshal(v, p)
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+package entities
+
+// SearchConfig text search configuration of products.search_vector, it must
+// match the configuration of the product search migration
+const SearchConfig = "simple"
@@ -1,11 +1,54 @@
 package entities
 
+import "golang.org/x/crypto/bcrypt"
+
 // User model
 type User struct {
 	Model
-	Username  string `json:"username"`
-	Email     string `json:"email"`
-	Password  string `json:"password"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	// Password bcrypt hash of the password, never serialized
+	Password  string `json:"-"`
 	Firstname string `json:"firstname"`
 	Lastname  string `json:"lastname"`
+	Roles     []Role `json:"roles,omitempty" gorm:"many2many:user_roles"`
 } //@name User
+
+// SetPassword store the bcrypt hash of password
+func (u *User) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.Password = string(hash)
+	return nil
+}
+
+// CheckPassword report whether password matches the stored hash
+func (u *User) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) == nil
+}
+
+// RoleNames return the name of each role of the user
+func (u *User) RoleNames() []string {
+	names := make([]string, len(u.Roles))
+	for i, role := range u.Roles {
+		names[i] = role.Name
+	}
+	return names
+}
+
+// Permissions return the permissions granted by all roles of the user
+func (u *User) Permissions() []string {
+	seen := map[string]bool{}
+	permissions := []string{}
+	for _, role := range u.Roles {
+		for _, permission := range role.Permissions {
+			if !seen[permission] {
+				seen[permission] = true
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	return permissions
+}
@@ -0,0 +1,23 @@
+package errors
+
+import (
+	"context"
+	stdErrors "errors"
+	"net/http"
+)
+
+// StatusClientClosedRequest non standard status, borrowed from nginx, of a
+// request whose client went away before the response
+const StatusClientClosedRequest = 499
+
+// FromContext map an error caused by the cancellation of the request context
+// to 499 and by its deadline to 504, it return nil for any other error
+func FromContext(err error) *Error {
+	switch {
+	case stdErrors.Is(err, context.Canceled):
+		return NewError(StatusClientClosedRequest, "request canceled")
+	case stdErrors.Is(err, context.DeadlineExceeded):
+		return NewError(http.StatusGatewayTimeout, "request timeout")
+	}
+	return nil
+}
@@ -20,6 +20,9 @@ func GinError() gin.HandlerFunc {
 		c.Next()
 		if errors := c.Errors.ByType(gin.ErrorTypeAny); len(errors) > 0 {
 			err := errors[0].Err
+			if ctxErr := FromContext(err); ctxErr != nil {
+				err = ctxErr
+			}
 			if err, ok := err.(*Error); ok {
 				log.Error(
 					fmt.Sprintf("%s: %s", tagAppError, err))
@@ -0,0 +1,55 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"gorm.io/gorm"
+)
+
+// DatabaseCheck ping the database behind db
+func DatabaseCheck(db *gorm.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// DialCheck open a connection to address, e.g. to verify the otlp collector
+// is reachable
+func DialCheck(network, address string) CheckFunc {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, network, address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// DiskConfig of the disk space check
+type DiskConfig struct {
+	Path string
+	// MinFree bytes available below which the check fails
+	MinFree uint64
+}
+
+// DiskSpaceCheck fail when the free space of the filesystem holding
+// cnf.Path drops below cnf.MinFree
+func DiskSpaceCheck(cnf DiskConfig) CheckFunc {
+	return func(ctx context.Context) error {
+		free, err := freeDiskSpace(cnf.Path)
+		if err != nil {
+			return err
+		}
+		if free < cnf.MinFree {
+			return fmt.Errorf("free disk space %d bytes is below %d bytes", free, cnf.MinFree)
+		}
+		return nil
+	}
+}
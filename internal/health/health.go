@@ -0,0 +1,263 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Status of a check or of the whole report
+type Status string
+
+const (
+	StatusUp       Status = "up"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// Config of the health subsystem
+type Config struct {
+	// Timeout of a single check run
+	Timeout time.Duration
+	// CacheTTL how long a check result is reused before the check runs again
+	CacheTTL time.Duration
+	Disk     DiskConfig
+}
+
+// CheckFunc report an unhealthy component by returning an error
+type CheckFunc func(ctx context.Context) error
+
+// Option customize a registered check
+type Option func(*check)
+
+// WithTimeout override the registry timeout for one check
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *check) {
+		c.timeout = timeout
+	}
+}
+
+// WithCacheTTL override the registry cache ttl for one check
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(c *check) {
+		c.ttl = ttl
+	}
+}
+
+// Optional mark the check as non critical, a failure degrades the report but
+// does not fail readiness
+func Optional() Option {
+	return func(c *check) {
+		c.optional = true
+	}
+}
+
+// Liveness include the check in the liveness report, by default checks only
+// count for readiness
+func Liveness() Option {
+	return func(c *check) {
+		c.liveness = true
+	}
+}
+
+// CheckResult latest result of a check
+type CheckResult struct {
+	Status      Status     `json:"status"`
+	Latency     string     `json:"latency"`
+	CheckedAt   time.Time  `json:"checkedAt"`
+	LastError   string     `json:"lastError,omitempty"`
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+} //@name HealthCheckResult
+
+// Report aggregated result of checks
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+} //@name HealthReport
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	timeout  time.Duration
+	ttl      time.Duration
+	optional bool
+	liveness bool
+
+	mu     sync.Mutex
+	result CheckResult
+	// running is closed when the in-flight run ends, nil when idle
+	running chan struct{}
+}
+
+// run return the cached result while it is fresh, otherwise it wait for a run
+// of the check. Concurrent probes share the same run, which is detached from
+// their contexts so a probe giving up does not fail the check for the others
+func (c *check) run(ctx context.Context) CheckResult {
+	c.mu.Lock()
+	if !c.result.CheckedAt.IsZero() && time.Since(c.result.CheckedAt) < c.ttl {
+		defer c.mu.Unlock()
+		return c.result
+	}
+	running := c.running
+	if running == nil {
+		running = make(chan struct{})
+		c.running = running
+		go c.execute(running)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-running:
+	case <-ctx.Done():
+		// the result of the caller is not cached, the run goes on for the
+		// next probes
+		now := time.Now()
+		return CheckResult{
+			Status:      StatusDown,
+			CheckedAt:   now,
+			LastError:   fmt.Sprintf("probe gave up: %s", ctx.Err()),
+			LastErrorAt: &now,
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.result
+}
+
+// execute the check and cache its result, then close running
+func (c *check) execute(running chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.fn(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timed out: %w", ctx.Err())
+	}
+
+	checkedAt := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.result.Latency = checkedAt.Sub(start).String()
+	c.result.CheckedAt = checkedAt
+	c.result.Status = StatusUp
+	if err != nil {
+		c.result.Status = StatusDown
+		c.result.LastError = err.Error()
+		c.result.LastErrorAt = &checkedAt
+	}
+	c.running = nil
+	close(running)
+}
+
+// Registry of named health checks
+type Registry struct {
+	cnf    Config
+	mu     sync.RWMutex
+	checks []*check
+}
+
+// NewRegistry create an empty registry
+func NewRegistry(cnf Config) *Registry {
+	return &Registry{cnf: cnf}
+}
+
+// Register add a named check, registering the same name twice replaces the
+// previous check
+func (r *Registry) Register(name string, fn CheckFunc, opts ...Option) {
+	c := &check{name: name, fn: fn, timeout: r.cnf.Timeout, ttl: r.cnf.CacheTTL}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.timeout <= 0 {
+		c.timeout = time.Second
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, registered := range r.checks {
+		if registered.name == name {
+			r.checks[i] = c
+			return
+		}
+	}
+	r.checks = append(r.checks, c)
+}
+
+// Live run the liveness checks
+func (r *Registry) Live(ctx context.Context) Report {
+	return r.report(ctx, true)
+}
+
+// Ready run all checks
+func (r *Registry) Ready(ctx context.Context) Report {
+	return r.report(ctx, false)
+}
+
+func (r *Registry) report(ctx context.Context, liveness bool) Report {
+	r.mu.RLock()
+	checks := make([]*check, 0, len(r.checks))
+	for _, c := range r.checks {
+		if !liveness || c.liveness {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(checks))}
+	for i, c := range checks {
+		result := results[i]
+		if result.Status == StatusDown {
+			if c.optional {
+				result.Status = StatusDegraded
+				if report.Status == StatusUp {
+					report.Status = StatusDegraded
+				}
+			} else {
+				report.Status = StatusDown
+			}
+		}
+		report.Checks[c.name] = result
+	}
+	return report
+}
+
+// LiveHandler serve the liveness report
+func (r *Registry) LiveHandler(w http.ResponseWriter, req *http.Request) {
+	writeReport(w, r.Live(req.Context()))
+}
+
+// ReadyHandler serve the readiness report
+func (r *Registry) ReadyHandler(w http.ResponseWriter, req *http.Request) {
+	writeReport(w, r.Ready(req.Context()))
+}
+
+func writeReport(w http.ResponseWriter, report Report) {
+	code := http.StatusOK
+	if report.Status == StatusDown {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,104 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-example/internal/health"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HealthTestSuite struct {
+	suite.Suite
+	registry *health.Registry
+}
+
+func (s *HealthTestSuite) SetupTest() {
+	s.registry = health.NewRegistry(health.Config{Timeout: 50 * time.Millisecond})
+}
+
+func (s *HealthTestSuite) TestReadyAllUp() {
+	s.registry.Register("ok", func(ctx context.Context) error { return nil })
+
+	report := s.registry.Ready(context.Background())
+	s.Equal(health.StatusUp, report.Status)
+	s.Equal(health.StatusUp, report.Checks["ok"].Status)
+}
+
+func (s *HealthTestSuite) TestOptionalFailureDegrades() {
+	s.registry.Register("ok", func(ctx context.Context) error { return nil })
+	s.registry.Register("collector", func(ctx context.Context) error { return errors.New("unreachable") }, health.Optional())
+
+	res := httptest.NewRecorder()
+	s.registry.ReadyHandler(res, httptest.NewRequest("GET", "/health/ready", nil))
+	s.Equal(http.StatusOK, res.Code, "Status must be 200:OK")
+
+	var report health.Report
+	s.NoError(json.NewDecoder(res.Body).Decode(&report))
+	s.Equal(health.StatusDegraded, report.Status)
+	s.Equal("unreachable", report.Checks["collector"].LastError)
+}
+
+func (s *HealthTestSuite) TestCriticalFailureFailsReadinessOnly() {
+	s.registry.Register("database", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	res := httptest.NewRecorder()
+	s.registry.ReadyHandler(res, httptest.NewRequest("GET", "/health/ready", nil))
+	s.Equal(http.StatusServiceUnavailable, res.Code, "Status must be 503:ServiceUnavailable")
+
+	res = httptest.NewRecorder()
+	s.registry.LiveHandler(res, httptest.NewRequest("GET", "/health/live", nil))
+	s.Equal(http.StatusOK, res.Code, "Status must be 200:OK")
+}
+
+func (s *HealthTestSuite) TestTimeout() {
+	s.registry.Register("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	report := s.registry.Ready(context.Background())
+	s.Equal(health.StatusDown, report.Status)
+	s.Contains(report.Checks["slow"].LastError, "timed out")
+}
+
+func (s *HealthTestSuite) TestCachedResult() {
+	calls := 0
+	s.registry.Register("cached", func(ctx context.Context) error {
+		calls++
+		return nil
+	}, health.WithCacheTTL(time.Minute))
+
+	s.registry.Ready(context.Background())
+	s.registry.Ready(context.Background())
+	s.Equal(1, calls)
+}
+
+func (s *HealthTestSuite) TestCanceledProbeIsNotCached() {
+	s.registry.Register("database", func(ctx context.Context) error {
+		select {
+		case <-time.After(10 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}, health.WithCacheTTL(time.Minute))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := s.registry.Ready(ctx)
+	s.Equal(health.StatusDown, report.Status, "the canceled probe gave up")
+
+	report = s.registry.Ready(context.Background())
+	s.Equal(health.StatusUp, report.Status, "the check must not fail with the context of another probe")
+	s.Empty(report.Checks["database"].LastError)
+}
+
+func TestHealthTestSuite(t *testing.T) {
+	suite.Run(t, new(HealthTestSuite))
+}
@@ -0,0 +1,209 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/resource"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+)
+
+// exporter send a batch of log records to the collector
+type exporter interface {
+	export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error
+	close() error
+}
+
+type grpcExporter struct {
+	conn   *grpc.ClientConn
+	client collogspb.LogsServiceClient
+}
+
+// newGRPCExporter connect in the background, an unreachable collector fails
+// the exports instead of the start of the service
+func newGRPCExporter(ctx context.Context, endpoint string, plaintext bool) (*grpcExporter, error) {
+	creds := credentials.NewTLS(&tls.Config{})
+	if plaintext {
+		creds = insecure.NewCredentials()
+	}
+	conn, err := grpc.DialContext(ctx, endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC connection to collector: %w", err)
+	}
+	return &grpcExporter{conn: conn, client: collogspb.NewLogsServiceClient(conn)}, nil
+}
+
+func (e *grpcExporter) export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error {
+	_, err := e.client.Export(ctx, req)
+	return err
+}
+
+func (e *grpcExporter) close() error {
+	return e.conn.Close()
+}
+
+type httpExporter struct {
+	url    string
+	client *http.Client
+}
+
+func newHTTPExporter(endpoint string, plaintext bool) *httpExporter {
+	url := endpoint
+	if !strings.Contains(url, "://") {
+		scheme := "https://"
+		if plaintext {
+			scheme = "http://"
+		}
+		url = scheme + url + "/v1/logs"
+	}
+	return &httpExporter{url: url, client: &http.Client{}}
+}
+
+func (e *httpExporter) export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/x-protobuf")
+	res, err := e.client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to export logs: %s", res.Status)
+	}
+	return nil
+}
+
+func (e *httpExporter) close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// batcher queue the records and export them from a single goroutine, a full
+// queue drop the records instead of blocking the logger
+type batcher struct {
+	exp     exporter
+	res     *resource.Resource
+	cnf     ExportConfig
+	records chan *logspb.LogRecord
+	dropped atomic.Int64
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	// err of the exports failed after stop, set before done is closed
+	err error
+}
+
+func newBatcher(exp exporter, res *resource.Resource, cnf ExportConfig) *batcher {
+	b := &batcher{
+		exp:     exp,
+		res:     res,
+		cnf:     cnf,
+		records: make(chan *logspb.LogRecord, cnf.QueueSize),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+func (b *batcher) enqueue(r *logspb.LogRecord) {
+	select {
+	case b.records <- r:
+	default:
+		b.dropped.Add(1)
+	}
+}
+
+func (b *batcher) run() {
+	defer close(b.done)
+	ticker := time.NewTicker(b.cnf.Interval)
+	defer ticker.Stop()
+
+	batch := make([]*logspb.LogRecord, 0, b.cnf.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), b.cnf.Timeout)
+		defer cancel()
+		err := b.exp.export(ctx, resourceLogs(b.res, batch))
+		if err != nil {
+			err = fmt.Errorf("failed to export %d log records: %w", len(batch), err)
+		}
+		batch = make([]*logspb.LogRecord, 0, b.cnf.BatchSize)
+		return err
+	}
+	// once stopped the errors are returned by shutdown, before logging the
+	// error would queue another record for the same exporter
+	handle := func(err error) {
+		select {
+		case <-b.stop:
+			b.err = errors.Join(b.err, err)
+		default:
+			if err != nil {
+				otel.Handle(err)
+			}
+		}
+	}
+	for {
+		select {
+		case r := <-b.records:
+			if batch = append(batch, r); len(batch) >= b.cnf.BatchSize {
+				handle(flush())
+			}
+		case <-ticker.C:
+			handle(flush())
+		case <-b.stop:
+			for {
+				select {
+				case r := <-b.records:
+					if batch = append(batch, r); len(batch) >= b.cnf.BatchSize {
+						handle(flush())
+					}
+				default:
+					handle(flush())
+					return
+				}
+			}
+		}
+	}
+}
+
+// shutdown export the queued records and close the exporter, records written
+// afterwards are dropped. It fails when the queued records are not exported,
+// records dropped by a full queue are only logged
+func (b *batcher) shutdown(ctx context.Context) error {
+	b.once.Do(func() { close(b.stop) })
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if n := b.dropped.Load(); n > 0 {
+		Warn("log records dropped, the export queue was full", Int64("dropped", n))
+	}
+	return errors.Join(b.err, b.exp.close())
+}
@@ -93,6 +93,13 @@ func (l *defaultLogEntry) Write(status, bytes int, header http.Header, elapsed t
 	l.Logger.Info(fmt.Sprint(extra), zap.Int("status", status), zap.Int("bytes", bytes), zap.Duration("elapsed", elapsed))
 }
 
+// WithFields add fields to the request log entry of r
+func WithFields(r *http.Request, fields ...Field) {
+	if entry, ok := chimw.GetLogEntry(r).(*defaultLogEntry); ok {
+		entry.Logger = entry.Logger.With(fields...)
+	}
+}
+
 func (l *defaultLogEntry) Panic(v interface{}, stack []byte) {
 	l.Logger.DPanic(fmt.Sprint(v))
 }
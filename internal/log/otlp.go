@@ -0,0 +1,305 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var ErrUndefindedLogProto = fmt.Errorf("undefined log protocol, available(http; grpc; none)")
+
+// instrumentationName scope of the exported log records
+const instrumentationName = "go-example/internal/log"
+
+// Config of the logger
+type Config struct {
+	zap.Config `mapstructure:",squash"`
+	// Export tee the records to an OTLP log exporter
+	Export ExportConfig
+}
+
+// ExportConfig of the OTLP log exporter. Records wait in a bounded queue and
+// are exported in batches, records are dropped when the queue is full so a
+// slow collector never blocks the caller
+type ExportConfig struct {
+	Proto string
+	// Endpoint host:port of the collector, the http protocol also accepts an
+	// url, https://host:port/v1/logs by default
+	Endpoint string
+	// Insecure export in plaintext instead of TLS, http://host:port/v1/logs
+	// for the http protocol
+	Insecure bool
+	// QueueSize max records waiting for export
+	QueueSize int
+	// BatchSize max records of an export
+	BatchSize int
+	// Interval between exports of incomplete batches
+	Interval time.Duration
+	// Timeout of an export
+	Timeout time.Duration
+}
+
+func (c ExportConfig) withDefaults() ExportConfig {
+	if c.QueueSize <= 0 {
+		c.QueueSize = 2048
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 512
+	}
+	if c.BatchSize > c.QueueSize {
+		c.BatchSize = c.QueueSize
+	}
+	if c.Interval <= 0 {
+		c.Interval = time.Second
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	return c
+}
+
+// InitExporter tee the records of the default logger to an OTLP log exporter,
+// the resource is the one of traces and metrics. The returned function export
+// the queued records and close the exporter, it must be called after the last
+// record is written
+func InitExporter(ctx context.Context, serviceName, serviceVersion string, cnf ExportConfig) (func(context.Context) error, error) {
+	if cnf.Proto == "none" {
+		return func(context.Context) error { return nil }, nil
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			semconv.ServiceName(serviceName),
+			semconv.ServiceVersion(serviceVersion),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	var exp exporter
+	switch cnf.Proto {
+	case "http":
+		exp = newHTTPExporter(cnf.Endpoint, cnf.Insecure)
+	case "grpc":
+		if exp, err = newGRPCExporter(ctx, cnf.Endpoint, cnf.Insecure); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrUndefindedLogProto
+	}
+
+	b := newBatcher(exp, res, cnf.withDefaults())
+	ResetDefault(std.Tee(&otlpCore{LevelEnabler: std.level, batcher: b}))
+	return b.shutdown, nil
+}
+
+// Tee return a logger writing its records to core as well
+func (l *Logger) Tee(core zapcore.Core) *Logger {
+	return &Logger{
+		l: l.l.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(c, core)
+		})),
+		level: l.level,
+	}
+}
+
+// otlpCore convert zap entries to OTLP log records and enqueue them for export
+type otlpCore struct {
+	zapcore.LevelEnabler
+	fields  []zapcore.Field
+	batcher *batcher
+}
+
+func (c *otlpCore) With(fields []zapcore.Field) zapcore.Core {
+	return &otlpCore{
+		LevelEnabler: c.LevelEnabler,
+		fields:       append(c.fields[:len(c.fields):len(c.fields)], fields...),
+		batcher:      c.batcher,
+	}
+}
+
+func (c *otlpCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(e.Level) {
+		return ce.AddCore(e, c)
+	}
+	return ce
+}
+
+func (c *otlpCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	attrs := mapKeyValues(enc.Fields)
+	if e.LoggerName != "" {
+		attrs = append(attrs, stringKeyValue("logger.name", e.LoggerName))
+	}
+	if e.Caller.Defined {
+		attrs = append(attrs,
+			stringKeyValue(string(semconv.CodeFilepathKey), e.Caller.File),
+			&commonpb.KeyValue{Key: string(semconv.CodeLineNumberKey), Value: &commonpb.AnyValue{
+				Value: &commonpb.AnyValue_IntValue{IntValue: int64(e.Caller.Line)}}},
+		)
+	}
+	if e.Stack != "" {
+		attrs = append(attrs, stringKeyValue(string(semconv.ExceptionStacktraceKey), e.Stack))
+	}
+	c.batcher.enqueue(&logspb.LogRecord{
+		TimeUnixNano:         uint64(e.Time.UnixNano()),
+		ObservedTimeUnixNano: uint64(time.Now().UnixNano()),
+		SeverityNumber:       severity(e.Level),
+		SeverityText:         e.Level.CapitalString(),
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: e.Message}},
+		Attributes:           attrs,
+	})
+	return nil
+}
+
+// Sync never wait for the collector, queued records are exported by the
+// shutdown function of InitExporter
+func (c *otlpCore) Sync() error {
+	return nil
+}
+
+// severity map zap levels like the otel zap bridge
+func severity(l zapcore.Level) logspb.SeverityNumber {
+	switch l {
+	case zapcore.DebugLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG
+	case zapcore.InfoLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_INFO
+	case zapcore.WarnLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_WARN
+	case zapcore.ErrorLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR
+	case zapcore.DPanicLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_FATAL
+	case zapcore.PanicLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_FATAL2
+	case zapcore.FatalLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_FATAL3
+	}
+	return logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED
+}
+
+func stringKeyValue(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
+
+// mapKeyValues convert the fields of a zap map encoder, sorted by key
+func mapKeyValues(m map[string]interface{}) []*commonpb.KeyValue {
+	kvs := make([]*commonpb.KeyValue, 0, len(m))
+	for k, v := range m {
+		kvs = append(kvs, &commonpb.KeyValue{Key: k, Value: anyValue(v)})
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	return kvs
+}
+
+func anyValue(v interface{}) *commonpb.AnyValue {
+	switch v := v.(type) {
+	case string:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v}}
+	case int:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int8:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int16:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v}}
+	case uint8:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case uint16:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case uint32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case uint:
+		return uintValue(uint64(v))
+	case uint64:
+		return uintValue(v)
+	case uintptr:
+		return uintValue(uint64(v))
+	case float32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: float64(v)}}
+	case float64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v}}
+	case []byte:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: v}}
+	case time.Time:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v.Format(time.RFC3339Nano)}}
+	case time.Duration:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v.String()}}
+	case map[string]interface{}:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{
+			KvlistValue: &commonpb.KeyValueList{Values: mapKeyValues(v)}}}
+	case []interface{}:
+		values := make([]*commonpb.AnyValue, len(v))
+		for i, item := range v {
+			values[i] = anyValue(item)
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{
+			ArrayValue: &commonpb.ArrayValue{Values: values}}}
+	}
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: fmt.Sprint(v)}}
+}
+
+// uintValue keep unsigned values above the int64 range as strings
+func uintValue(v uint64) *commonpb.AnyValue {
+	if v > math.MaxInt64 {
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: fmt.Sprint(v)}}
+	}
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+}
+
+// resourceLogs wrap the records of a batch with the resource and the scope
+func resourceLogs(res *resource.Resource, records []*logspb.LogRecord) *collogspb.ExportLogsServiceRequest {
+	attrs := make([]*commonpb.KeyValue, 0, res.Len())
+	for _, kv := range res.Attributes() {
+		attrs = append(attrs, attributeKeyValue(kv))
+	}
+	return &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			Resource: &resourcepb.Resource{Attributes: attrs},
+			ScopeLogs: []*logspb.ScopeLogs{{
+				Scope:      &commonpb.InstrumentationScope{Name: instrumentationName},
+				LogRecords: records,
+			}},
+			SchemaUrl: res.SchemaURL(),
+		}},
+	}
+}
+
+func attributeKeyValue(kv attribute.KeyValue) *commonpb.KeyValue {
+	var v *commonpb.AnyValue
+	switch kv.Value.Type() {
+	case attribute.BOOL:
+		v = anyValue(kv.Value.AsBool())
+	case attribute.INT64:
+		v = anyValue(kv.Value.AsInt64())
+	case attribute.FLOAT64:
+		v = anyValue(kv.Value.AsFloat64())
+	default:
+		v = anyValue(kv.Value.Emit())
+	}
+	return &commonpb.KeyValue{Key: string(kv.Key), Value: v}
+}
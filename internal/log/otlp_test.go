@@ -0,0 +1,150 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"go-example/internal/log"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// useLogger set a default logger writing to the returned buffer
+func useLogger(t *testing.T) *bytes.Buffer {
+	std := log.Default()
+	buf := &bytes.Buffer{}
+	log.ResetDefault(log.New(buf, zap.NewProductionConfig()))
+	t.Cleanup(func() { log.ResetDefault(std) })
+	return buf
+}
+
+func TestInitExporter(t *testing.T) {
+	useLogger(t)
+	var (
+		mu       sync.Mutex
+		requests []*collogspb.ExportLogsServiceRequest
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/logs", r.URL.Path)
+		require.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		b, _ := io.ReadAll(r.Body)
+		req := &collogspb.ExportLogsServiceRequest{}
+		require.NoError(t, proto.Unmarshal(b, req))
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	shutdown, err := log.InitExporter(context.Background(), "server", "test", log.ExportConfig{
+		Proto:    "http",
+		Endpoint: strings.TrimPrefix(collector.URL, "http://"),
+		Insecure: true,
+	})
+	require.NoError(t, err)
+	log.Default().Debug("below the level")
+	log.Info("user created", log.String("user", "alice"), log.Int("attempt", 2))
+	log.Error("failed", log.Bool("retry", false))
+	require.NoError(t, shutdown(context.Background()))
+
+	require.Len(t, requests, 1)
+	rl := requests[0].ResourceLogs[0]
+	resource := map[string]string{}
+	for _, kv := range rl.Resource.Attributes {
+		resource[kv.Key] = kv.Value.GetStringValue()
+	}
+	require.Equal(t, "server", resource["service.name"])
+	require.Equal(t, "test", resource["service.version"])
+
+	records := rl.ScopeLogs[0].LogRecords
+	require.Len(t, records, 2)
+	require.Equal(t, "user created", records[0].Body.GetStringValue())
+	require.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_INFO, records[0].SeverityNumber)
+	attrs := map[string]interface{}{}
+	for _, kv := range records[0].Attributes {
+		switch {
+		case kv.Value.GetStringValue() != "":
+			attrs[kv.Key] = kv.Value.GetStringValue()
+		default:
+			attrs[kv.Key] = kv.Value.GetIntValue()
+		}
+	}
+	require.Equal(t, "alice", attrs["user"])
+	require.Equal(t, int64(2), attrs["attempt"])
+	require.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, records[1].SeverityNumber)
+}
+
+func TestSlowCollectorNeverBlocks(t *testing.T) {
+	logs := useLogger(t)
+	release := make(chan struct{})
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer collector.Close()
+	defer close(release)
+
+	shutdown, err := log.InitExporter(context.Background(), "server", "test", log.ExportConfig{
+		Proto:     "http",
+		Endpoint:  collector.URL,
+		QueueSize: 4,
+		BatchSize: 2,
+		Timeout:   50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	start := time.Now()
+	for i := 0; i < 1000; i++ {
+		log.Info("request served")
+	}
+	require.Less(t, time.Since(start), time.Second)
+	require.ErrorContains(t, shutdown(context.Background()), "failed to export")
+	require.Contains(t, logs.String(), "log records dropped")
+}
+
+func TestUnreachableCollectorDoesNotBlockStart(t *testing.T) {
+	useLogger(t)
+	start := time.Now()
+	shutdown, err := log.InitExporter(context.Background(), "server", "test", log.ExportConfig{
+		Proto:    "grpc",
+		Endpoint: "127.0.0.1:1",
+		Insecure: true,
+		Timeout:  100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	require.Less(t, time.Since(start), 100*time.Millisecond)
+
+	log.Info("request served")
+	require.ErrorContains(t, shutdown(context.Background()), "failed to export")
+}
+
+func TestShutdownWithDroppedRecords(t *testing.T) {
+	logs := useLogger(t)
+	release := make(chan struct{})
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer collector.Close()
+
+	shutdown, err := log.InitExporter(context.Background(), "server", "test", log.ExportConfig{
+		Proto:     "http",
+		Endpoint:  collector.URL,
+		QueueSize: 4,
+		BatchSize: 2,
+	})
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		log.Info("request served")
+	}
+	close(release)
+	require.NoError(t, shutdown(context.Background()), "dropped records are not an export failure")
+	require.Contains(t, logs.String(), `"dropped"`)
+}
@@ -0,0 +1,67 @@
+package metric
+
+import (
+	"context"
+	"database/sql"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelMetric "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+)
+
+// instrumentationName name of the meter of the instruments of this package
+const instrumentationName = "go-example/internal/metric"
+
+var (
+	stateUsed = attribute.String("state", "used")
+	stateIdle = attribute.String("state", "idle")
+)
+
+// RegisterDBStats observe sql.DB.Stats of db on every collection as gauges,
+// the wait count and wait duration are the totals since the pool was opened.
+// attrs identify the pool, e.g. pool.name. The returned function stop the
+// observation
+func RegisterDBStats(provider otelMetric.MeterProvider, db *sql.DB, attrs ...attribute.KeyValue) (func() error, error) {
+	meter := provider.Meter(instrumentationName)
+
+	usage, err := meter.Int64ObservableGauge("db.client.connections.usage",
+		instrument.WithUnit("{connection}"),
+		instrument.WithDescription("The number of connections that are currently in state described by the state attribute"))
+	if err != nil {
+		return nil, err
+	}
+	maxOpen, err := meter.Int64ObservableGauge("db.client.connections.max",
+		instrument.WithUnit("{connection}"),
+		instrument.WithDescription("The maximum number of open connections allowed"))
+	if err != nil {
+		return nil, err
+	}
+	waitCount, err := meter.Int64ObservableGauge("db.client.connections.wait_count",
+		instrument.WithUnit("{wait}"),
+		instrument.WithDescription("The total number of connections waited for"))
+	if err != nil {
+		return nil, err
+	}
+	waitTime, err := meter.Float64ObservableGauge("db.client.connections.wait_time",
+		instrument.WithUnit("ms"),
+		instrument.WithDescription("The total time blocked waiting for a new connection"))
+	if err != nil {
+		return nil, err
+	}
+
+	used := append([]attribute.KeyValue{stateUsed}, attrs...)
+	idle := append([]attribute.KeyValue{stateIdle}, attrs...)
+	registration, err := meter.RegisterCallback(func(ctx context.Context, o otelMetric.Observer) error {
+		stats := db.Stats()
+		o.ObserveInt64(usage, int64(stats.InUse), used...)
+		o.ObserveInt64(usage, int64(stats.Idle), idle...)
+		o.ObserveInt64(maxOpen, int64(stats.MaxOpenConnections), attrs...)
+		o.ObserveInt64(waitCount, stats.WaitCount, attrs...)
+		o.ObserveFloat64(waitTime, float64(stats.WaitDuration.Microseconds())/1000, attrs...)
+		return nil
+	}, usage, maxOpen, waitCount, waitTime)
+	if err != nil {
+		return nil, err
+	}
+	return registration.Unregister, nil
+}
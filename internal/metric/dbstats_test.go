@@ -0,0 +1,49 @@
+package metric_test
+
+import (
+	"context"
+	"go-example/internal/metric"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestRegisterDBStats(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	db.SetMaxOpenConns(7)
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	unregister, err := metric.RegisterDBStats(provider, db, attribute.String("pool.name", "primary"))
+	require.NoError(t, err)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	names := map[string]metricdata.Aggregation{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		names[m.Name] = m.Data
+	}
+	require.Contains(t, names, "db.client.connections.usage")
+	require.IsType(t, metricdata.Gauge[int64]{}, names["db.client.connections.wait_count"])
+	require.IsType(t, metricdata.Gauge[float64]{}, names["db.client.connections.wait_time"])
+	maxOpen := names["db.client.connections.max"].(metricdata.Gauge[int64])
+	require.Equal(t, int64(7), maxOpen.DataPoints[0].Value)
+	name, _ := maxOpen.DataPoints[0].Attributes.Value("pool.name")
+	require.Equal(t, "primary", name.AsString())
+
+	require.NoError(t, unregister())
+	rm = metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if gauge, ok := m.Data.(metricdata.Gauge[int64]); ok {
+				require.Empty(t, gauge.DataPoints, "%s observed after unregister", m.Name)
+			}
+		}
+	}
+}
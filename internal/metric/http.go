@@ -0,0 +1,113 @@
+package metric
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go-example/internal/route"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	otelMetric "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// statusClassKey status code family of the response, e.g. 2xx, which keeps
+// the number of series low compared to http.status_code
+var statusClassKey = attribute.Key("http.status_class")
+
+// httpInstruments of the RED metrics of the http server
+type httpInstruments struct {
+	duration     instrument.Float64Histogram
+	active       instrument.Int64UpDownCounter
+	requestSize  instrument.Int64Histogram
+	responseSize instrument.Int64Histogram
+	requests     instrument.Int64Counter
+}
+
+// Middleware record the rate, errors and duration of the requests. Every
+// instrument is labelled by http.method, http.route and http.status_class,
+// but http.server.active_requests which only knows the method. The route is
+// resolved by chi or by route.Set from a mounted router, requests matching no
+// route have no http.route
+func Middleware(provider otelMetric.MeterProvider) (func(http.Handler) http.Handler, error) {
+	meter := provider.Meter(instrumentationName)
+	var (
+		m   httpInstruments
+		err error
+	)
+	if m.duration, err = meter.Float64Histogram("http.server.duration",
+		instrument.WithUnit("ms"),
+		instrument.WithDescription("Measures the duration of inbound HTTP requests")); err != nil {
+		return nil, err
+	}
+	if m.active, err = meter.Int64UpDownCounter("http.server.active_requests",
+		instrument.WithUnit("{request}"),
+		instrument.WithDescription("Measures the number of concurrent HTTP requests that are currently in-flight")); err != nil {
+		return nil, err
+	}
+	if m.requestSize, err = meter.Int64Histogram("http.server.request.size",
+		instrument.WithUnit("By"),
+		instrument.WithDescription("Measures the size of HTTP request messages")); err != nil {
+		return nil, err
+	}
+	if m.responseSize, err = meter.Int64Histogram("http.server.response.size",
+		instrument.WithUnit("By"),
+		instrument.WithDescription("Measures the size of HTTP response messages")); err != nil {
+		return nil, err
+	}
+	if m.requests, err = meter.Int64Counter("http.server.request.count",
+		instrument.WithUnit("{request}"),
+		instrument.WithDescription("Counts the inbound HTTP requests")); err != nil {
+		return nil, err
+	}
+	return m.middleware, nil
+}
+
+func (m httpInstruments) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx := r.Context()
+		method := semconv.HTTPMethod(r.Method)
+		m.active.Add(ctx, 1, method)
+		defer m.active.Add(ctx, -1, method)
+
+		body := &countingReader{ReadCloser: r.Body}
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = body
+		}
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		r = route.Track(r)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		attrs := []attribute.KeyValue{method, statusClassKey.String(strconv.Itoa(status/100) + "xx")}
+		if pattern := route.Pattern(r); pattern != "" {
+			attrs = append(attrs, semconv.HTTPRoute(pattern))
+		}
+		m.duration.Record(ctx, float64(time.Since(start).Microseconds())/1000, attrs...)
+		m.requestSize.Record(ctx, body.n.Load(), attrs...)
+		m.responseSize.Record(ctx, int64(ww.BytesWritten()), attrs...)
+		m.requests.Add(ctx, 1, attrs...)
+	})
+}
+
+// countingReader count the bytes of the request body read by the handler,
+// Content-Length is unknown for chunked requests
+type countingReader struct {
+	io.ReadCloser
+	n atomic.Int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,80 @@
+package metric_test
+
+import (
+	"context"
+	"go-example/internal/metric"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMiddleware(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	middleware, err := metric.Middleware(provider)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(middleware)
+	r.Post("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})
+	r.Get("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	for _, id := range []string{"1", "2"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/items/"+id, strings.NewReader("body")))
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fail", nil))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	metrics := map[string]metricdata.Aggregation{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m.Data
+	}
+
+	duration := metrics["http.server.duration"].(metricdata.Histogram)
+	require.Len(t, duration.DataPoints, 2, "one series per route and status class")
+	series := map[string]metricdata.HistogramDataPoint{}
+	for _, dp := range duration.DataPoints {
+		route, _ := dp.Attributes.Value("http.route")
+		class, _ := dp.Attributes.Value("http.status_class")
+		series[route.AsString()+" "+class.AsString()] = dp
+	}
+	require.Equal(t, uint64(2), series["/items/{id} 2xx"].Count)
+	require.Equal(t, uint64(1), series["/fail 5xx"].Count)
+
+	requests := metrics["http.server.request.count"].(metricdata.Sum[int64])
+	require.Len(t, requests.DataPoints, 2)
+
+	requestSize := metrics["http.server.request.size"].(metricdata.Histogram)
+	responseSize := metrics["http.server.response.size"].(metricdata.Histogram)
+	for _, dp := range requestSize.DataPoints {
+		if route, _ := dp.Attributes.Value("http.route"); route.AsString() == "/items/{id}" {
+			require.Equal(t, float64(8), dp.Sum)
+		}
+	}
+	for _, dp := range responseSize.DataPoints {
+		if route, _ := dp.Attributes.Value("http.route"); route.AsString() == "/items/{id}" {
+			require.Equal(t, float64(14), dp.Sum)
+		}
+	}
+
+	active := metrics["http.server.active_requests"].(metricdata.Sum[int64])
+	for _, dp := range active.DataPoints {
+		require.Zero(t, dp.Value, "every request has ended")
+		_, hasRoute := dp.Attributes.Value(attribute.Key("http.route"))
+		require.False(t, hasRoute)
+	}
+}
@@ -2,11 +2,21 @@ package metric
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	otelPrometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	otelMetric "go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/metric/global"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -15,11 +25,40 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 )
 
-var ErrUndefindedMetricProto = fmt.Errorf("undefined metric protocol, available(http; grpc)")
+var ErrUndefindedMetricProto = fmt.Errorf("undefined metric protocol, available(http; grpc; prometheus; stdout; none)")
+
+// listeners serving the prometheus endpoint
+const (
+	ListenerAdmin  = "admin"
+	ListenerPublic = "public"
+)
 
 type Config struct {
 	Proto    string
 	Endpoint string
+	// Listener serving /metrics when Proto is prometheus, admin (default) or
+	// public
+	Listener string
+	// Output file of the stdout protocol, stderr when empty
+	Output string
+}
+
+// ServedOn report the listener serving /metrics
+func (c Config) ServedOn() string {
+	if c.Listener == "" {
+		return ListenerAdmin
+	}
+	return c.Listener
+}
+
+// handler of the prometheus endpoint, nil unless Proto is prometheus
+var handler http.Handler
+
+// Handler serve the metrics in the Prometheus and OpenMetrics text formats,
+// negotiated by the Accept header. It is nil unless the meter provider was
+// initialized with the prometheus protocol
+func Handler() http.Handler {
+	return handler
 }
 
 func InitMeterProvider(ctx context.Context, serviceName, serviceVersion string, cnf Config) (func(context.Context) error, error) {
@@ -37,14 +76,17 @@ func InitMeterProvider(ctx context.Context, serviceName, serviceVersion string,
 	ctx, cancel := context.WithTimeout(ctx, time.Second)
 	defer cancel()
 
-	var metricExporter sdkmetric.Exporter
+	var reader sdkmetric.Reader
+	closeOutput := func() error { return nil }
 
 	switch cnf.Proto {
 	case "http":
 		// Set up a metric exporter
-		if metricExporter, err = otlpmetrichttp.New(ctx, otlpmetrichttp.WithEndpoint(cnf.Endpoint)); err != nil {
+		metricExporter, err := otlpmetrichttp.New(ctx, otlpmetrichttp.WithEndpoint(cnf.Endpoint))
+		if err != nil {
 			return nil, fmt.Errorf("failed to create metric exporter: %w", err)
 		}
+		reader = sdkmetric.NewPeriodicReader(metricExporter)
 	case "grpc":
 		// Set up a metric exporter
 		conn, err := grpc.DialContext(ctx, cnf.Endpoint,
@@ -57,22 +99,64 @@ func InitMeterProvider(ctx context.Context, serviceName, serviceVersion string,
 		}
 
 		// Set up a trace exporter
-		if metricExporter, err = otlpmetricgrpc.New(ctx, otlpmetricgrpc.WithGRPCConn(conn)); err != nil {
+		metricExporter, err := otlpmetricgrpc.New(ctx, otlpmetricgrpc.WithGRPCConn(conn))
+		if err != nil {
 			return nil, fmt.Errorf("failed to create trace exporter: %w", err)
 		}
+		reader = sdkmetric.NewPeriodicReader(metricExporter)
+	case "prometheus":
+		if cnf.Listener != "" && cnf.Listener != ListenerAdmin && cnf.Listener != ListenerPublic {
+			return nil, fmt.Errorf("undefined metric listener %q, available(%s; %s)", cnf.Listener, ListenerAdmin, ListenerPublic)
+		}
+		// a dedicated registry serves the otel instruments only, without the
+		// default collectors of the prometheus client
+		registry := prometheus.NewRegistry()
+		if reader, err = otelPrometheus.New(otelPrometheus.WithRegisterer(registry)); err != nil {
+			return nil, fmt.Errorf("failed to create prometheus exporter: %w", err)
+		}
+		handler = promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+	case "stdout":
+		var w io.Writer
+		if w, closeOutput, err = openOutput(cnf.Output); err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "\t")
+		metricExporter, err := stdoutmetric.New(stdoutmetric.WithEncoder(encoder))
+		if err != nil {
+			closeOutput()
+			return nil, fmt.Errorf("failed to create metric exporter: %w", err)
+		}
+		reader = sdkmetric.NewPeriodicReader(metricExporter)
+	case "none":
+		global.SetMeterProvider(otelMetric.NewNoopMeterProvider())
+		return func(context.Context) error { return nil }, nil
 	default:
 		return nil, ErrUndefindedMetricProto
 	}
 
-	pr := sdkmetric.NewPeriodicReader(metricExporter)
 	meterProvider := sdkmetric.NewMeterProvider(
-		sdkmetric.WithReader(pr),
+		sdkmetric.WithReader(reader),
 		sdkmetric.WithResource(res),
 	)
 
 	global.SetMeterProvider(meterProvider)
 
-	return meterProvider.Shutdown, nil
+	return func(ctx context.Context) error {
+		return errors.Join(meterProvider.Shutdown(ctx), closeOutput())
+	}, nil
+}
+
+// openOutput open the file of the stdout exporter, stderr when path is empty
+func openOutput(path string) (io.Writer, func() error, error) {
+	if path == "" {
+		return os.Stderr, func() error { return nil }, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open metric output: %w", err)
+	}
+	return f, f.Close, nil
 }
 
 // // This is just an example, see the the contrib runtime instrumentation for real implementation.
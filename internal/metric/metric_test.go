@@ -0,0 +1,60 @@
+package metric_test
+
+import (
+	"context"
+	"go-example/internal/metric"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric/global"
+)
+
+func TestPrometheusHandler(t *testing.T) {
+	shutdown, err := metric.InitMeterProvider(context.Background(), "server", "test", metric.Config{Proto: "prometheus"})
+	require.NoError(t, err)
+	defer shutdown(context.Background())
+	require.NotNil(t, metric.Handler())
+
+	counter, err := global.MeterProvider().Meter("test").Int64Counter("jobs.done")
+	require.NoError(t, err)
+	counter.Add(context.Background(), 3)
+
+	res := httptest.NewRecorder()
+	metric.Handler().ServeHTTP(res, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Contains(t, res.Header().Get("Content-Type"), "text/plain")
+	require.Contains(t, res.Body.String(), "jobs_done_total")
+	require.Contains(t, res.Body.String(), `service_name="server"`)
+
+	res = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=0.0.1")
+	metric.Handler().ServeHTTP(res, req)
+	require.Contains(t, res.Header().Get("Content-Type"), "application/openmetrics-text")
+	require.Contains(t, res.Body.String(), "# EOF")
+
+	_, err = metric.InitMeterProvider(context.Background(), "server", "test", metric.Config{Proto: "prometheus", Listener: "sidecar"})
+	require.Error(t, err)
+}
+
+func TestStdoutExporter(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "metrics.json")
+	shutdown, err := metric.InitMeterProvider(context.Background(), "server", "test", metric.Config{Proto: "stdout", Output: output})
+	require.NoError(t, err)
+	counter, err := global.MeterProvider().Meter("test").Int64Counter("jobs.done")
+	require.NoError(t, err)
+	counter.Add(context.Background(), 3)
+	// shutdown export the pending measurements
+	require.NoError(t, shutdown(context.Background()))
+	b, err := os.ReadFile(output)
+	require.NoError(t, err)
+	require.Contains(t, string(b), `"Name": "jobs.done"`)
+
+	shutdown, err = metric.InitMeterProvider(context.Background(), "server", "test", metric.Config{Proto: "none"})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+}
@@ -0,0 +1,348 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockKey postgres advisory lock held while migrating, so replicas starting
+// together apply every migration once
+const lockKey int64 = 4672158730465402181
+
+//go:embed sql/*.sql
+var embedded embed.FS
+
+var (
+	// ErrUnknownVersion version has no migration file
+	ErrUnknownVersion = errors.New("unknown migration version")
+	// ErrNoDownMigration migration can not be reverted
+	ErrNoDownMigration = errors.New("migration has no down script")
+
+	fileRegexp = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+	nameRegexp = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// Migration versioned change of the schema, Down revert Up
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Status of a migration, AppliedAt is nil while pending
+type Status struct {
+	Version   uint64
+	Name      string
+	AppliedAt *time.Time
+}
+
+// FS embedded migrations of the service
+func FS() fs.FS {
+	sub, err := fs.Sub(embedded, "sql")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// Load read the migrations of fsys, files are named
+// <version>_<name>.up.sql and <version>_<name>.down.sql
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[uint64]*Migration{}
+	for _, entry := range entries {
+		match := fileRegexp.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		script, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is both %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(script)
+		} else {
+			m.Down = string(script)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %s has no up script", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator apply migrations and record them in the schema_migrations table
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New create migrator of the migrations in fsys
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up apply every pending migration
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.migrate(ctx, func(applied map[uint64]time.Time) ([]Migration, []Migration, error) {
+		return m.pending(applied, ^uint64(0)), nil, nil
+	})
+}
+
+// Down revert the n last applied migrations
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	return m.migrate(ctx, func(applied map[uint64]time.Time) ([]Migration, []Migration, error) {
+		reverts := m.applied(applied, 0)
+		if n < len(reverts) {
+			reverts = reverts[:n]
+		}
+		return nil, reverts, nil
+	})
+}
+
+// To apply or revert migrations until version is the last applied one,
+// version 0 revert every migration
+func (m *Migrator) To(ctx context.Context, version uint64) ([]Migration, error) {
+	if version != 0 {
+		if _, ok := m.find(version); !ok {
+			return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+		}
+	}
+	return m.migrate(ctx, func(applied map[uint64]time.Time) ([]Migration, []Migration, error) {
+		return m.pending(applied, version), m.applied(applied, version), nil
+	})
+}
+
+// Status list every migration and the applied versions without migration
+// file
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err := createTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	applied, names, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	status := []Status{}
+	for _, migration := range m.migrations {
+		s := Status{Version: migration.Version, Name: migration.Name}
+		if at, ok := applied[migration.Version]; ok {
+			s.AppliedAt = &at
+		}
+		status = append(status, s)
+	}
+	for version, at := range applied {
+		if _, ok := m.find(version); !ok {
+			at := at
+			status = append(status, Status{Version: version, Name: names[version], AppliedAt: &at})
+		}
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Version < status[j].Version })
+	return status, nil
+}
+
+// migrate hold the advisory lock on a dedicated connection, plan the
+// migrations from the applied versions then apply ups in ascending and downs
+// in descending order, each in its own transaction
+func (m *Migrator) migrate(ctx context.Context, plan func(applied map[uint64]time.Time) (ups, downs []Migration, err error)) (done []Migration, err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return nil, fmt.Errorf("failed to lock schema migrations: %w", err)
+	}
+	defer func() {
+		// the lock is released with the session when unlock fails
+		if _, unlockErr := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey); unlockErr != nil && err == nil {
+			err = fmt.Errorf("failed to unlock schema migrations: %w", unlockErr)
+		}
+	}()
+
+	if err := createTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	applied, _, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	ups, downs, err := plan(applied)
+	if err != nil {
+		return nil, err
+	}
+	for _, migration := range downs {
+		if migration.Down == "" {
+			return done, fmt.Errorf("%w: %s", ErrNoDownMigration, migration)
+		}
+		err := apply(ctx, conn, migration.Down,
+			"DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+		if err != nil {
+			return done, fmt.Errorf("failed to revert migration %s: %w", migration, err)
+		}
+		done = append(done, migration)
+	}
+	for _, migration := range ups {
+		err := apply(ctx, conn, migration.Up,
+			"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+		if err != nil {
+			return done, fmt.Errorf("failed to apply migration %s: %w", migration, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// pending migrations up to version in ascending order
+func (m *Migrator) pending(applied map[uint64]time.Time, version uint64) []Migration {
+	pending := []Migration{}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok && migration.Version <= version {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+// applied migrations above version in descending order
+func (m *Migrator) applied(applied map[uint64]time.Time, version uint64) []Migration {
+	reverts := []Migration{}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; ok && migration.Version > version {
+			reverts = append(reverts, migration)
+		}
+	}
+	return reverts
+}
+
+func (m *Migrator) find(version uint64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+func createTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version bigint PRIMARY KEY,
+	name text NOT NULL,
+	applied_at timestamptz NOT NULL DEFAULT now()
+)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[uint64]time.Time, map[uint64]string, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+	applied := map[uint64]time.Time{}
+	names := map[uint64]string{}
+	for rows.Next() {
+		var (
+			version   uint64
+			name      string
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &name, &appliedAt); err != nil {
+			return nil, nil, err
+		}
+		applied[version] = appliedAt
+		names[version] = name
+	}
+	return applied, names, rows.Err()
+}
+
+// apply run script and record it in one transaction
+func apply(ctx context.Context, conn *sql.Conn, script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Create write empty up and down scripts of a new migration in dir, the
+// version follows the last migration of dir
+func Create(dir, name string) (up, down string, err error) {
+	name = strings.Trim(nameRegexp.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", errors.New("migration name must contain letters or digits")
+	}
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+	migration := Migration{Version: 1, Name: name}
+	if len(migrations) > 0 {
+		migration.Version = migrations[len(migrations)-1].Version + 1
+	}
+	up = filepath.Join(dir, migration.String()+".up.sql")
+	down = filepath.Join(dir, migration.String()+".down.sql")
+	if err := os.WriteFile(up, []byte("-- "+migration.String()+" up\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(down, []byte("-- "+migration.String()+" down\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	return up, down, nil
+}
@@ -0,0 +1,160 @@
+package migrations_test
+
+import (
+	"context"
+	"go-example/internal/migrations"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+var testFS = fstest.MapFS{
+	"0001_init.up.sql":     {Data: []byte("CREATE TABLE a (id text)")},
+	"0001_init.down.sql":   {Data: []byte("DROP TABLE a")},
+	"0002_second.up.sql":   {Data: []byte("CREATE TABLE b (id text)")},
+	"0002_second.down.sql": {Data: []byte("DROP TABLE b")},
+	"0003_third.up.sql":    {Data: []byte("CREATE TABLE c (id text)")},
+	"README.md":            {Data: []byte("ignored")},
+}
+
+type MigratorTestSuite struct {
+	suite.Suite
+	mock     sqlmock.Sqlmock
+	migrator *migrations.Migrator
+}
+
+func (s *MigratorTestSuite) SetupTest() {
+	db, mock, err := sqlmock.New()
+	require.NoError(s.T(), err)
+	s.mock = mock
+	s.migrator, err = migrations.New(db, testFS)
+	require.NoError(s.T(), err)
+}
+
+func (s *MigratorTestSuite) TearDownTest() {
+	s.NoError(s.mock.ExpectationsWereMet())
+}
+
+// expectApplied lock the migrations and return the applied versions
+func (s *MigratorTestSuite) expectApplied(versions ...uint64) {
+	s.mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	rows := sqlmock.NewRows([]string{"version", "name", "applied_at"})
+	for _, version := range versions {
+		rows.AddRow(version, "m", time.Now())
+	}
+	s.mock.ExpectQuery("SELECT version, name, applied_at FROM schema_migrations").WillReturnRows(rows)
+}
+
+func (s *MigratorTestSuite) expectUnlock() {
+	s.mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func (s *MigratorTestSuite) TestUpApplyPending() {
+	s.expectApplied(1)
+	for _, m := range []struct {
+		version uint64
+		name    string
+		script  string
+	}{{2, "second", "CREATE TABLE b"}, {3, "third", "CREATE TABLE c"}} {
+		s.mock.ExpectBegin()
+		s.mock.ExpectExec(m.script).WillReturnResult(sqlmock.NewResult(0, 0))
+		s.mock.ExpectExec("INSERT INTO schema_migrations").
+			WithArgs(m.version, m.name).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.mock.ExpectCommit()
+	}
+	s.expectUnlock()
+
+	done, err := s.migrator.Up(context.Background())
+	s.NoError(err)
+	s.Len(done, 2)
+}
+
+func (s *MigratorTestSuite) TestUpStopOnFailure() {
+	s.expectApplied()
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("CREATE TABLE a").WillReturnError(os.ErrInvalid)
+	s.mock.ExpectRollback()
+	s.expectUnlock()
+
+	done, err := s.migrator.Up(context.Background())
+	s.ErrorContains(err, "0001_init")
+	s.Empty(done)
+}
+
+func (s *MigratorTestSuite) TestDownRevertLastApplied() {
+	s.expectApplied(1, 2)
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec("DROP TABLE b").WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec("DELETE FROM schema_migrations").
+		WithArgs(uint64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+	s.expectUnlock()
+
+	done, err := s.migrator.Down(context.Background(), 1)
+	s.NoError(err)
+	s.Equal(uint64(2), done[0].Version)
+}
+
+func (s *MigratorTestSuite) TestDownWithoutScript() {
+	s.expectApplied(1, 2, 3)
+	s.expectUnlock()
+
+	_, err := s.migrator.Down(context.Background(), 1)
+	s.ErrorIs(err, migrations.ErrNoDownMigration)
+}
+
+func (s *MigratorTestSuite) TestToUnknownVersion() {
+	_, err := s.migrator.To(context.Background(), 9)
+	s.ErrorIs(err, migrations.ErrUnknownVersion)
+}
+
+func (s *MigratorTestSuite) TestStatus() {
+	s.mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectQuery("SELECT version, name, applied_at FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "applied_at"}).
+			AddRow(1, "init", time.Now()).
+			AddRow(7, "removed", time.Now()))
+
+	status, err := s.migrator.Status(context.Background())
+	s.NoError(err)
+	s.Len(status, 4)
+	s.NotNil(status[0].AppliedAt)
+	s.Nil(status[1].AppliedAt)
+	s.Equal("removed", status[3].Name)
+}
+
+func TestMigratorTestSuite(t *testing.T) {
+	suite.Run(t, new(MigratorTestSuite))
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	all, err := migrations.Load(migrations.FS())
+	require.NoError(t, err)
+	for _, m := range all {
+		require.NotEmpty(t, m.Down, "migration %s must be reversible", m)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "0004_old.up.sql"), []byte("SELECT 1"), 0o644))
+
+	up, down, err := migrations.Create(dir, "Add Product Stock")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "0005_add_product_stock.up.sql"), up)
+	require.FileExists(t, down)
+}
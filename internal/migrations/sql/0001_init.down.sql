@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_products_code;
+DROP INDEX IF EXISTS idx_users_email;
+DROP INDEX IF EXISTS idx_users_username;
+DROP TABLE IF EXISTS product_props;
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,60 @@
+-- schema of the entities, IF NOT EXISTS adopts databases created by the
+-- former gorm auto migration
+CREATE TABLE IF NOT EXISTS roles (
+	id text PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	name text,
+	permissions text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_name ON roles (name);
+
+CREATE TABLE IF NOT EXISTS users (
+	id text PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	username text,
+	email text,
+	password text,
+	firstname text,
+	lastname text
+);
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users (created_at, id);
+-- soft deleted rows keep their values, so only the live rows are unique
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS user_roles (
+	user_id text,
+	role_id text,
+	PRIMARY KEY (user_id, role_id),
+	CONSTRAINT fk_user_roles_user FOREIGN KEY (user_id) REFERENCES users (id),
+	CONSTRAINT fk_user_roles_role FOREIGN KEY (role_id) REFERENCES roles (id)
+);
+
+CREATE TABLE IF NOT EXISTS products (
+	id text PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	name text,
+	code text,
+	price bigint,
+	attr text
+);
+CREATE INDEX IF NOT EXISTS idx_products_created_at_id ON products (created_at, id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_code ON products (code) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS product_props (
+	id text PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	key text,
+	value text,
+	product_ref text,
+	CONSTRAINT fk_products_props FOREIGN KEY (product_ref) REFERENCES products (id)
+);
+CREATE INDEX IF NOT EXISTS idx_product_props_product_ref ON product_props (product_ref);
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS product_props_search_vector_update ON product_props;
+DROP FUNCTION IF EXISTS product_props_search_vector_update();
+DROP TRIGGER IF EXISTS products_search_vector_update ON products;
+DROP FUNCTION IF EXISTS products_search_vector_update();
+DROP FUNCTION IF EXISTS products_search_vector(products);
+DROP INDEX IF EXISTS idx_products_search_vector;
+ALTER TABLE products DROP COLUMN IF EXISTS search_vector;
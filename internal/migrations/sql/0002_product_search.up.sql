@@ -0,0 +1,45 @@
+-- products.search_vector is a weighted tsvector of the name, code and prop
+-- values of a product. The product trigger refresh it on insert and on name or
+-- code changes, the props trigger refresh the product of every inserted,
+-- updated or (soft) deleted prop
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector;
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector);
+
+CREATE OR REPLACE FUNCTION products_search_vector(p products) RETURNS tsvector AS $$
+	SELECT setweight(to_tsvector('simple', coalesce(p.name, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce(p.code, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce(string_agg(pp.value, ' '), '')), 'B')
+	FROM product_props pp
+	WHERE pp.product_ref = p.id AND pp.deleted_at IS NULL
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION products_search_vector_update() RETURNS trigger AS $$
+BEGIN
+	NEW.search_vector := products_search_vector(NEW);
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS products_search_vector_update ON products;
+CREATE TRIGGER products_search_vector_update
+	BEFORE INSERT OR UPDATE OF name, code ON products
+	FOR EACH ROW EXECUTE FUNCTION products_search_vector_update();
+
+CREATE OR REPLACE FUNCTION product_props_search_vector_update() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP IN ('UPDATE', 'DELETE') THEN
+		UPDATE products SET search_vector = products_search_vector(products) WHERE id = OLD.product_ref;
+	END IF;
+	IF TG_OP IN ('INSERT', 'UPDATE') THEN
+		UPDATE products SET search_vector = products_search_vector(products) WHERE id = NEW.product_ref;
+	END IF;
+	RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS product_props_search_vector_update ON product_props;
+CREATE TRIGGER product_props_search_vector_update
+	AFTER INSERT OR UPDATE OR DELETE ON product_props
+	FOR EACH ROW EXECUTE FUNCTION product_props_search_vector_update();
+
+UPDATE products SET search_vector = products_search_vector(products) WHERE search_vector IS NULL;
@@ -0,0 +1,107 @@
+package queryspec
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Row read the value of a column of an in-memory row. Strings, integers,
+// floats and time.Time values are supported
+type Row func(column string) interface{}
+
+// Match report whether row meets every condition of the spec, the in-memory
+// counterpart of Filter
+func (s *Spec) Match(row Row) bool {
+	if s == nil {
+		return true
+	}
+	for _, c := range s.Conditions {
+		value := row(c.Column)
+		if c.Operator == Contains {
+			if !strings.Contains(strings.ToLower(fmt.Sprint(value)), strings.ToLower(fmt.Sprint(c.Value))) {
+				return false
+			}
+			continue
+		}
+		cmp := CompareValues(value, c.Value)
+		var ok bool
+		switch c.Operator {
+		case Eq:
+			ok = cmp == 0
+		case Ne:
+			ok = cmp != 0
+		case Gt:
+			ok = cmp > 0
+		case Gte:
+			ok = cmp >= 0
+		case Lt:
+			ok = cmp < 0
+		case Lte:
+			ok = cmp <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare order rows a and b by the orders of the spec, the in-memory
+// counterpart of Order. The result is negative when a sorts first and 0 when
+// the orders do not separate the rows
+func (s *Spec) Compare(a, b Row) int {
+	if s == nil {
+		return 0
+	}
+	for _, o := range s.Orders {
+		cmp := CompareValues(a(o.Column), b(o.Column))
+		if o.Desc {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp
+		}
+	}
+	return 0
+}
+
+// CompareValues compare two values of the same kind, numbers of any type are
+// compared as float64
+func CompareValues(a, b interface{}) int {
+	if x, ok := toFloat(a); ok {
+		if y, ok := toFloat(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	if x, ok := a.(time.Time); ok {
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	}
+	return 0, false
+}
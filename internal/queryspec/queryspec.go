@@ -0,0 +1,274 @@
+package queryspec
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Kind of the values of a field
+type Kind int
+
+const (
+	String Kind = iota
+	Number
+	Time
+)
+
+// Operator of a filter condition
+type Operator string
+
+const (
+	Eq       Operator = "="
+	Ne       Operator = "!="
+	Gt       Operator = ">"
+	Gte      Operator = ">="
+	Lt       Operator = "<"
+	Lte      Operator = "<="
+	Contains Operator = "~"
+)
+
+// operators ordered longest first so ">=" is not read as ">"
+var operators = []Operator{Gte, Lte, Ne, Eq, Gt, Lt, Contains}
+
+var kindOperators = map[Kind][]Operator{
+	String: {Eq, Ne, Contains},
+	Number: {Eq, Ne, Gt, Gte, Lt, Lte},
+	Time:   {Eq, Ne, Gt, Gte, Lt, Lte},
+}
+
+var kindNames = map[Kind]string{
+	String: "a string",
+	Number: "a number",
+	Time:   "an RFC3339 time",
+}
+
+// Field of the allowlist, Column is the database column of the field
+type Field struct {
+	Column   string
+	Kind     Kind
+	Sortable bool
+}
+
+// Schema allowlist of the fields clients can filter and sort on, keyed by the
+// field name of the api
+type Schema map[string]Field
+
+// Error invalid filter or sort parameter
+type Error struct {
+	Param   string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Param, e.Message)
+}
+
+// Condition of a filter
+type Condition struct {
+	Column   string
+	Operator Operator
+	Value    interface{}
+}
+
+// Order of a sort
+type Order struct {
+	Column string
+	Desc   bool
+}
+
+// Spec parsed filter and sort of a listing
+type Spec struct {
+	Conditions []Condition
+	Orders     []Order
+}
+
+// Parse validate filter and sort against schema. filter is a comma separated
+// list of conditions like "price>=100,code~ABC", a comma in a value is
+// escaped as "\,". sort is a comma separated list of fields, prefixed by "-"
+// for descending order
+func Parse(schema Schema, filter, sort string) (*Spec, error) {
+	spec := &Spec{}
+	for _, expr := range splitEscaped(filter) {
+		condition, err := parseCondition(schema, expr)
+		if err != nil {
+			return nil, err
+		}
+		spec.Conditions = append(spec.Conditions, *condition)
+	}
+	for _, expr := range strings.Split(sort, ",") {
+		expr = strings.TrimSpace(expr)
+		if expr == "" {
+			continue
+		}
+		order := Order{}
+		name := expr
+		switch expr[0] {
+		case '-':
+			order.Desc = true
+			name = expr[1:]
+		case '+':
+			name = expr[1:]
+		}
+		field, ok := schema[name]
+		if !ok {
+			return nil, &Error{Param: "sort", Message: fmt.Sprintf("unknown field %q, available(%s)", name, schema.names(true))}
+		}
+		if !field.Sortable {
+			return nil, &Error{Param: "sort", Message: fmt.Sprintf("field %q is not sortable", name)}
+		}
+		order.Column = field.Column
+		spec.Orders = append(spec.Orders, order)
+	}
+	return spec, nil
+}
+
+func parseCondition(schema Schema, expr string) (*Condition, error) {
+	end := 0
+	for end < len(expr) && isNameChar(expr[end]) {
+		end++
+	}
+	name, rest := expr[:end], expr[end:]
+	if name == "" {
+		return nil, &Error{Param: "filter", Message: fmt.Sprintf("missing field in %q", expr)}
+	}
+	field, ok := schema[name]
+	if !ok {
+		return nil, &Error{Param: "filter", Message: fmt.Sprintf("unknown field %q in %q, available(%s)", name, expr, schema.names(false))}
+	}
+
+	var operator Operator
+	for _, op := range operators {
+		if strings.HasPrefix(rest, string(op)) {
+			operator = op
+			break
+		}
+	}
+	if operator == "" {
+		return nil, &Error{Param: "filter", Message: fmt.Sprintf("missing operator in %q", expr)}
+	}
+	if !field.allows(operator) {
+		return nil, &Error{Param: "filter", Message: fmt.Sprintf("operator %q is not supported by field %q in %q", operator, name, expr)}
+	}
+
+	raw := rest[len(operator):]
+	var value interface{} = raw
+	switch field.Kind {
+	case Number:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, &Error{Param: "filter", Message: fmt.Sprintf("field %q expects %s in %q", name, kindNames[field.Kind], expr)}
+		}
+		value = number
+	case Time:
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, &Error{Param: "filter", Message: fmt.Sprintf("field %q expects %s in %q", name, kindNames[field.Kind], expr)}
+		}
+		value = t
+	}
+	return &Condition{Column: field.Column, Operator: operator, Value: value}, nil
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c == '.' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// splitEscaped split s on the commas not escaped by a backslash
+func splitEscaped(s string) []string {
+	var (
+		parts   []string
+		current strings.Builder
+	)
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == ',':
+			current.WriteByte(',')
+			i++
+		case s[i] == ',':
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(s[i])
+		}
+	}
+	parts = append(parts, current.String())
+
+	nonEmpty := parts[:0]
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return nonEmpty
+}
+
+func (f Field) allows(operator Operator) bool {
+	for _, op := range kindOperators[f.Kind] {
+		if op == operator {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Schema) names(sortable bool) string {
+	names := make([]string, 0, len(s))
+	for name, field := range s {
+		if !sortable || field.Sortable {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, "; ")
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ContainsPattern match s anywhere in a LIKE expression
+func ContainsPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+// Filter add the conditions of the spec to query
+func (s *Spec) Filter(query *gorm.DB) *gorm.DB {
+	if s == nil {
+		return query
+	}
+	for _, c := range s.Conditions {
+		column := clause.Column{Name: c.Column}
+		switch c.Operator {
+		case Eq:
+			query = query.Where(clause.Eq{Column: column, Value: c.Value})
+		case Ne:
+			query = query.Where(clause.Neq{Column: column, Value: c.Value})
+		case Gt:
+			query = query.Where(clause.Gt{Column: column, Value: c.Value})
+		case Gte:
+			query = query.Where(clause.Gte{Column: column, Value: c.Value})
+		case Lt:
+			query = query.Where(clause.Lt{Column: column, Value: c.Value})
+		case Lte:
+			query = query.Where(clause.Lte{Column: column, Value: c.Value})
+		case Contains:
+			query = query.Where("? ILIKE ?", column, ContainsPattern(fmt.Sprint(c.Value)))
+		}
+	}
+	return query
+}
+
+// Order add the orders of the spec to query
+func (s *Spec) Order(query *gorm.DB) *gorm.DB {
+	if s == nil {
+		return query
+	}
+	for _, o := range s.Orders {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: o.Column}, Desc: o.Desc})
+	}
+	return query
+}
@@ -0,0 +1,99 @@
+package queryspec_test
+
+import (
+	"go-example/internal/queryspec"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+var schema = queryspec.Schema{
+	"name":      {Column: "name", Kind: queryspec.String, Sortable: true},
+	"code":      {Column: "code", Kind: queryspec.String},
+	"price":     {Column: "price", Kind: queryspec.Number, Sortable: true},
+	"createdAt": {Column: "created_at", Kind: queryspec.Time, Sortable: true},
+}
+
+type product struct {
+	Name string
+}
+
+type QuerySpecTestSuite struct {
+	suite.Suite
+	DB *gorm.DB
+}
+
+func (s *QuerySpecTestSuite) SetupSuite() {
+	db, _, err := sqlmock.New()
+	require.NoError(s.T(), err)
+	s.DB, err = gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{DryRun: true})
+	require.NoError(s.T(), err)
+}
+
+func (s *QuerySpecTestSuite) sql(spec *queryspec.Spec) (string, []interface{}) {
+	stmt := spec.Order(spec.Filter(s.DB.Model(&product{}))).Find(&[]product{}).Statement
+	return stmt.SQL.String(), stmt.Vars
+}
+
+func (s *QuerySpecTestSuite) TestParseFilterAndSort() {
+	spec, err := queryspec.Parse(schema, `price>=100,code~A\,B,name!=pen`, "-price,name")
+	s.Require().NoError(err)
+
+	sql, vars := s.sql(spec)
+	s.Equal(`SELECT * FROM "products" WHERE "price" >= $1 AND "code" ILIKE $2 AND "name" <> $3 ORDER BY "price" DESC,"name"`, sql)
+	s.Equal([]interface{}{float64(100), "%A,B%", "pen"}, vars)
+}
+
+func (s *QuerySpecTestSuite) TestEmpty() {
+	spec, err := queryspec.Parse(schema, "", "")
+	s.Require().NoError(err)
+	s.Empty(spec.Conditions)
+	s.Empty(spec.Orders)
+
+	sql, _ := s.sql(nil)
+	s.Equal(`SELECT * FROM "products"`, sql)
+}
+
+func (s *QuerySpecTestSuite) TestInvalid() {
+	cases := map[string][2]string{
+		`unknown field "colour" in "colour=red", available(code; createdAt; name; price)`: {"colour=red", ""},
+		`operator ">" is not supported by field "name" in "name>a"`:                       {"name>a", ""},
+		`field "price" expects a number in "price>=abc"`:                                  {"price>=abc", ""},
+		`field "createdAt" expects an RFC3339 time in "createdAt<yesterday"`:              {"createdAt<yesterday", ""},
+		`missing operator in "price"`:                                                     {"price", ""},
+		`unknown field "colour", available(createdAt; name; price)`:                       {"", "-colour"},
+		`field "code" is not sortable`:                                                    {"", "code"},
+	}
+	for message, params := range cases {
+		_, err := queryspec.Parse(schema, params[0], params[1])
+		var specErr *queryspec.Error
+		s.Require().ErrorAs(err, &specErr, message)
+		s.Equal(message, specErr.Message)
+	}
+}
+
+func (s *QuerySpecTestSuite) TestMatchAndCompare() {
+	spec, err := queryspec.Parse(schema, `price>=100,code~ab`, "-price,name")
+	s.Require().NoError(err)
+	row := func(name, code string, price uint) queryspec.Row {
+		return func(column string) interface{} {
+			return map[string]interface{}{"name": name, "code": code, "price": price}[column]
+		}
+	}
+
+	s.True(spec.Match(row("Pen", "XABX", 100)))
+	s.False(spec.Match(row("Pen", "XABX", 99)))
+	s.False(spec.Match(row("Pen", "XY", 100)))
+
+	s.Negative(spec.Compare(row("Pen", "", 200), row("Pen", "", 100)))
+	s.Negative(spec.Compare(row("Eraser", "", 100), row("Pen", "", 100)))
+	s.Zero(spec.Compare(row("Pen", "A", 100), row("Pen", "B", 100)))
+}
+
+func TestQuerySpecTestSuite(t *testing.T) {
+	suite.Run(t, new(QuerySpecTestSuite))
+}
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"go-example/internal/database"
+	"go-example/internal/dto"
+	"go-example/internal/entities"
+	"go-example/internal/queryspec"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormProductRepository struct {
+	db *gorm.DB
+}
+
+// NewGormProductRepository store products in db, reads of List, Search and
+// Get are served by the replicas unless the context requires read your writes
+func NewGormProductRepository(db *gorm.DB) ProductRepository {
+	return &gormProductRepository{db: db}
+}
+
+func (r gormProductRepository) List(ctx context.Context, pageable dto.Pageable, spec *queryspec.Spec) ([]entities.Product, *dto.Page, error) {
+	pageable.Normalize()
+	products := []entities.Product{}
+	query := search(r.db.WithContext(database.ReadOnly(ctx)).Model(&entities.Product{}), pageable.Search, "name", "code")
+	page, err := paginate(query, pageable, spec, &products)
+	if err != nil {
+		return nil, nil, contextError(ctx, err)
+	}
+	return products, page, nil
+}
+
+func (r gormProductRepository) Search(ctx context.Context, req dto.SearchRequest) ([]ProductSearchHit, *dto.Page, error) {
+	tsquery, err := toTSQuery(req.Query, req.Prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	pageable := req.Pageable()
+	db := r.db.WithContext(database.ReadOnly(ctx))
+	var total int64
+	if err := db.Raw(productSearchCount, entities.SearchConfig, tsquery).Scan(&total).Error; err != nil {
+		return nil, nil, contextError(ctx, err)
+	}
+	hits := []ProductSearchHit{}
+	err = db.Raw(productSearchQuery,
+		entities.SearchConfig, headlineOptions,
+		entities.SearchConfig, tsquery,
+		pageable.Limit, pageable.Offset,
+	).Scan(&hits).Error
+	if err != nil {
+		return nil, nil, contextError(ctx, err)
+	}
+	return hits, &dto.Page{Total: &total}, nil
+}
+
+func (r gormProductRepository) Get(ctx context.Context, id string) (*entities.Product, error) {
+	product := &entities.Product{}
+	err := r.db.WithContext(database.ReadOnly(ctx)).Preload("Props").
+		First(product, entities.Product{Model: entities.Model{ID: id}}).Error
+	if err != nil {
+		return nil, contextError(ctx, notFound(err))
+	}
+	return product, nil
+}
+
+// Create insert the product and its props in one transaction then reload it
+// from the primary
+func (r gormProductRepository) Create(ctx context.Context, product *entities.Product) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		props := product.Props
+		if err := ensureUniqueProduct(tx, "", product.Code); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(product).Error; err != nil {
+			return err
+		}
+		_, err := syncProps(tx, product.ID, nil, props)
+		return err
+	})
+	if err != nil {
+		return contextError(ctx, duplicate(err))
+	}
+	stored, err := r.Get(database.ReadYourWrites(ctx), product.ID)
+	if err != nil {
+		return err
+	}
+	*product = *stored
+	return nil
+}
+
+// Update lock the product row until the changes of fn are saved, then reload
+// it from the primary
+func (r gormProductRepository) Update(ctx context.Context, id string, fn func(product *entities.Product) error) (*entities.Product, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		product, err := lockProduct(tx, id)
+		if err != nil {
+			return err
+		}
+		// fn may change the loaded props in place
+		stored := append([]entities.ProductProps(nil), product.Props...)
+		code := product.Code
+		if err := fn(product); err != nil {
+			return err
+		}
+		if err := ensureUniqueProduct(tx, id, changed(code, product.Code)); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Save(product).Error; err != nil {
+			return err
+		}
+		_, err = syncProps(tx, product.ID, stored, product.Props)
+		return err
+	})
+	if err != nil {
+		return nil, contextError(ctx, duplicate(err))
+	}
+	return r.Get(database.ReadYourWrites(ctx), id)
+}
+
+func (r gormProductRepository) Delete(ctx context.Context, id string) error {
+	rs := r.db.WithContext(ctx).Delete(&entities.Product{Model: entities.Model{ID: id}})
+	if rs.Error != nil {
+		return contextError(ctx, rs.Error)
+	}
+	if rs.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ensureUniqueProduct fail with ErrDuplicate when another product than id
+// already has the code, an empty code is not checked
+func ensureUniqueProduct(tx *gorm.DB, id, code string) error {
+	if code == "" {
+		return nil
+	}
+	query := tx.Model(&entities.Product{}).Where("code = ?", code)
+	if id != "" {
+		query = query.Where("id <> ?", id)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+// lockProduct load the product and its props, the row stays locked until the
+// transaction ends
+func lockProduct(tx *gorm.DB, id string) (*entities.Product, error) {
+	product := &entities.Product{}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Props").
+		First(product, entities.Product{Model: entities.Model{ID: id}}).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return product, nil
+}
+
+// syncProps replace the stored props of a product by the wanted ones, matched
+// by key: changed values are updated, new keys created and missing keys
+// deleted. It returns the wanted props as stored
+func syncProps(tx *gorm.DB, productID string, stored, wanted []entities.ProductProps) ([]entities.ProductProps, error) {
+	byKey := map[string]entities.ProductProps{}
+	for _, prop := range stored {
+		byKey[prop.Key] = prop
+	}
+	wanted = uniqueProps(wanted)
+	result := make([]entities.ProductProps, 0, len(wanted))
+	seen := map[string]bool{}
+	for _, prop := range wanted {
+		seen[prop.Key] = true
+		current, ok := byKey[prop.Key]
+		switch {
+		case !ok:
+			current = entities.ProductProps{Key: prop.Key, Value: prop.Value, ProductRef: productID}
+			if err := tx.Create(&current).Error; err != nil {
+				return nil, err
+			}
+		case current.Value != prop.Value:
+			current.Value = prop.Value
+			if err := tx.Save(&current).Error; err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, current)
+	}
+	for _, prop := range stored {
+		if seen[prop.Key] {
+			continue
+		}
+		prop := prop
+		if err := tx.Delete(&prop).Error; err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// uniqueProps keep one prop by key at the position of its first occurrence,
+// with the value of the last one
+func uniqueProps(props []entities.ProductProps) []entities.ProductProps {
+	index := map[string]int{}
+	unique := make([]entities.ProductProps, 0, len(props))
+	for _, prop := range props {
+		if i, ok := index[prop.Key]; ok {
+			unique[i].Value = prop.Value
+			continue
+		}
+		index[prop.Key] = len(unique)
+		unique = append(unique, prop)
+	}
+	return unique
+}
@@ -0,0 +1,56 @@
+package repository_test
+
+import (
+	"context"
+	"go-example/internal/entities"
+	"go-example/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestGormReportsContextErrors(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}))
+	require.NoError(t, err)
+	users := repository.NewGormUserRepository(gdb)
+
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = users.Get(ctx, "1")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = users.Get(ctx, "1")
+	require.ErrorIs(t, err, context.Canceled)
+	require.NotErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestGormReportsUniqueViolations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}))
+	require.NoError(t, err)
+	users := repository.NewGormUserRepository(gdb)
+
+	// a concurrent transaction inserted the same username after the check
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(`INSERT INTO "users"`).
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_users_username"})
+	mock.ExpectRollback()
+	err = users.Create(context.Background(), &entities.User{Username: "utain", Email: "utain@example.com"})
+	require.ErrorIs(t, err, repository.ErrDuplicate)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-example/internal/database"
+	"go-example/internal/dto"
+	"go-example/internal/entities"
+	"go-example/internal/queryspec"
+
+	"github.com/jackc/pgconn"
+	"gorm.io/gorm"
+)
+
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+// NewGormUserRepository store users in db, reads of List and Get are served
+// by the replicas unless the context requires read your writes
+func NewGormUserRepository(db *gorm.DB) UserRepository {
+	return &gormUserRepository{db: db}
+}
+
+func (r gormUserRepository) List(ctx context.Context, pageable dto.Pageable, spec *queryspec.Spec) ([]entities.User, *dto.Page, error) {
+	pageable.Normalize()
+	users := []entities.User{}
+	query := search(r.db.WithContext(database.ReadOnly(ctx)).Model(&entities.User{}), pageable.Search, "username", "email")
+	page, err := paginate(query, pageable, spec, &users)
+	if err != nil {
+		return nil, nil, contextError(ctx, err)
+	}
+	return users, page, nil
+}
+
+func (r gormUserRepository) Get(ctx context.Context, id string) (*entities.User, error) {
+	user := &entities.User{}
+	err := r.db.WithContext(database.ReadOnly(ctx)).First(user, &entities.User{Model: entities.Model{ID: id}}).Error
+	if err != nil {
+		return nil, contextError(ctx, notFound(err))
+	}
+	return user, nil
+}
+
+func (r gormUserRepository) GetByUsername(ctx context.Context, username string) (*entities.User, error) {
+	user := &entities.User{}
+	err := r.db.WithContext(ctx).Preload("Roles").Where("username = ?", username).First(user).Error
+	if err != nil {
+		return nil, contextError(ctx, notFound(err))
+	}
+	return user, nil
+}
+
+func (r gormUserRepository) Create(ctx context.Context, user *entities.User) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureUniqueUser(tx, "", user.Username, user.Email); err != nil {
+			return err
+		}
+		return tx.Create(user).Error
+	})
+	return contextError(ctx, duplicate(err))
+}
+
+func (r gormUserRepository) Update(ctx context.Context, id string, fn func(user *entities.User) error) (*entities.User, error) {
+	user := &entities.User{}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(user, &entities.User{Model: entities.Model{ID: id}}).Error; err != nil {
+			return notFound(err)
+		}
+		username, email := user.Username, user.Email
+		if err := fn(user); err != nil {
+			return err
+		}
+		// only the changed keys can collide with another user
+		if err := ensureUniqueUser(tx, id, changed(username, user.Username), changed(email, user.Email)); err != nil {
+			return err
+		}
+		return tx.Save(user).Error
+	})
+	if err != nil {
+		return nil, contextError(ctx, duplicate(err))
+	}
+	return user, nil
+}
+
+func (r gormUserRepository) Delete(ctx context.Context, id string) error {
+	rs := r.db.WithContext(ctx).Delete(&entities.User{Model: entities.Model{ID: id}})
+	if rs.Error != nil {
+		return contextError(ctx, rs.Error)
+	}
+	if rs.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ensureUniqueUser fail with ErrDuplicate when another user than id already
+// has the username or the email, empty values are not checked. Concurrent
+// transactions are not visible to the check, the unique indexes catch them
+// and duplicate translates their violation
+func ensureUniqueUser(tx *gorm.DB, id, username, email string) error {
+	query := tx.Model(&entities.User{})
+	switch {
+	case username != "" && email != "":
+		query = query.Where("username = ? OR email = ?", username, email)
+	case username != "":
+		query = query.Where("username = ?", username)
+	case email != "":
+		query = query.Where("email = ?", email)
+	default:
+		return nil
+	}
+	if id != "" {
+		query = query.Where("id <> ?", id)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+// changed return after when it differs from before, empty otherwise
+func changed(before, after string) string {
+	if before == after {
+		return ""
+	}
+	return after
+}
+
+// uniqueViolation SQLSTATE of postgres when a unique index rejects a row
+const uniqueViolation = "23505"
+
+// duplicate translate unique violations to ErrDuplicate
+func duplicate(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// notFound translate gorm.ErrRecordNotFound to ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
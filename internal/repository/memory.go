@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"go-example/internal/dto"
+	"go-example/internal/entities"
+	"go-example/internal/queryspec"
+	"html"
+	"sort"
+	"strings"
+)
+
+// modelColumn read the columns of entities.Model for queryspec.Row
+func modelColumn(m entities.Model, column string) interface{} {
+	switch column {
+	case "id":
+		return m.ID
+	case "created_at":
+		return m.CreatedAt
+	case "updated_at":
+		return m.UpdatedAt
+	}
+	return nil
+}
+
+// contains report whether one of values contains text, ignoring case like
+// the ILIKE of search
+func contains(text string, values ...string) bool {
+	if text == "" {
+		return true
+	}
+	text = strings.ToLower(text)
+	for _, value := range values {
+		if strings.Contains(strings.ToLower(value), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// paginateRows is the in-memory counterpart of paginate, rows are filtered
+// and sorted by spec then by (created_at, id). model return the embedded
+// entities.Model of a row and row its columns
+func paginateRows[T any](rows []T, pageable dto.Pageable, spec *queryspec.Spec, model func(T) entities.Model, row func(T) queryspec.Row) ([]T, *dto.Page, error) {
+	matches := []T{}
+	for _, r := range rows {
+		if spec.Match(row(r)) {
+			matches = append(matches, r)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if cmp := spec.Compare(row(matches[i]), row(matches[j])); cmp != 0 {
+			return cmp < 0
+		}
+		return before(model(matches[i]), model(matches[j]))
+	})
+
+	if pageable.Cursor != nil {
+		if spec != nil && len(spec.Orders) > 0 {
+			return nil, nil, ErrSortWithCursor
+		}
+		if *pageable.Cursor != "" {
+			after, err := decodeCursor(*pageable.Cursor)
+			if err != nil {
+				return nil, nil, err
+			}
+			start := sort.Search(len(matches), func(i int) bool {
+				return before(entities.Model{ID: after.ID, CreatedAt: after.CreatedAt}, model(matches[i]))
+			})
+			matches = matches[start:]
+		}
+		page := &dto.Page{}
+		if len(matches) > pageable.Limit {
+			matches = matches[:pageable.Limit]
+			last := model(matches[len(matches)-1])
+			page.NextCursor = cursor{CreatedAt: last.CreatedAt, ID: last.ID}.encode()
+		}
+		return matches, page, nil
+	}
+
+	total := int64(len(matches))
+	return window(matches, pageable.Offset, pageable.Limit), &dto.Page{Total: &total}, nil
+}
+
+// before report whether a sorts before b in the creation order
+func before(a, b entities.Model) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
+
+// window return at most limit rows from offset
+func window[T any](rows []T, offset, limit int) []T {
+	if offset >= len(rows) {
+		return []T{}
+	}
+	rows = rows[offset:]
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+// matchWords count the words of text matching one of words, with prefix a
+// longer word also matches. It return 0 unless every word matches and the
+// html escaped text with the matching words highlighted like the snippet of
+// ts_headline
+func matchWords(text string, words []string, prefix bool) (int, string) {
+	fields := strings.Fields(text)
+	found := map[string]bool{}
+	count := 0
+	for i, field := range fields {
+		fields[i] = html.EscapeString(field)
+		matched := false
+		for _, token := range searchWords(field) {
+			token = strings.ToLower(token)
+			for _, word := range words {
+				if token == word || prefix && strings.HasPrefix(token, word) {
+					found[word] = true
+					matched = true
+				}
+			}
+		}
+		if matched {
+			count++
+			fields[i] = "<mark>" + fields[i] + "</mark>"
+		}
+	}
+	for _, word := range words {
+		if !found[word] {
+			return 0, ""
+		}
+	}
+	return count, strings.Join(fields, " ")
+}
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"go-example/internal/dto"
+	"go-example/internal/entities"
+	"go-example/internal/queryspec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type memoryProductRepository struct {
+	mu       sync.RWMutex
+	products map[string]*entities.Product
+}
+
+// NewMemoryProductRepository store products in memory, safe for concurrent
+// use. The given products are stored as is, with their IDs and props
+func NewMemoryProductRepository(products ...entities.Product) ProductRepository {
+	r := &memoryProductRepository{products: map[string]*entities.Product{}}
+	for _, product := range products {
+		product := copyProduct(&product)
+		r.products[product.ID] = product
+	}
+	return r
+}
+
+func (r *memoryProductRepository) List(ctx context.Context, pageable dto.Pageable, spec *queryspec.Spec) ([]entities.Product, *dto.Page, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	pageable.Normalize()
+	r.mu.RLock()
+	rows := []entities.Product{}
+	for _, product := range r.products {
+		if contains(pageable.Search, product.Name, product.Code) {
+			rows = append(rows, *copyProduct(product))
+		}
+	}
+	r.mu.RUnlock()
+	return paginateRows(rows, pageable, spec,
+		func(p entities.Product) entities.Model { return p.Model },
+		productRow,
+	)
+}
+
+// Search rank a product by the number of its words matching the query, it
+// approximates the full-text search of postgres without stemming
+func (r *memoryProductRepository) Search(ctx context.Context, req dto.SearchRequest) ([]ProductSearchHit, *dto.Page, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	words := searchWords(req.Query)
+	if len(words) == 0 {
+		return nil, nil, ErrEmptySearch
+	}
+	for i := range words {
+		words[i] = strings.ToLower(words[i])
+	}
+	r.mu.RLock()
+	hits := []ProductSearchHit{}
+	for _, product := range r.products {
+		text := []string{product.Name, product.Code}
+		for _, prop := range product.Props {
+			text = append(text, prop.Value)
+		}
+		count, snippet := matchWords(strings.Join(text, " "), words, req.Prefix)
+		if count == 0 {
+			continue
+		}
+		hits = append(hits, ProductSearchHit{
+			Product: *copyProduct(product),
+			Rank:    float64(count),
+			Snippet: snippet,
+		})
+	}
+	r.mu.RUnlock()
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+		return before(hits[i].Model, hits[j].Model)
+	})
+	pageable := req.Pageable()
+	total := int64(len(hits))
+	return window(hits, pageable.Offset, pageable.Limit), &dto.Page{Total: &total}, nil
+}
+
+func (r *memoryProductRepository) Get(ctx context.Context, id string) (*entities.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	product, ok := r.products[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyProduct(product), nil
+}
+
+func (r *memoryProductRepository) Create(ctx context.Context, product *entities.Product) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if product.ID == "" {
+		id, err := entities.NewID()
+		if err != nil {
+			return err
+		}
+		product.ID = id
+	}
+	now := time.Now()
+	product.CreatedAt, product.UpdatedAt = now, now
+	props, err := storeProps(product.ID, nil, product.Props, now)
+	if err != nil {
+		return err
+	}
+	product.Props = props
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.exists("", product.Code) {
+		return ErrDuplicate
+	}
+	r.products[product.ID] = copyProduct(product)
+	return nil
+}
+
+func (r *memoryProductRepository) Update(ctx context.Context, id string, fn func(product *entities.Product) error) (*entities.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.products[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	product := copyProduct(stored)
+	if err := fn(product); err != nil {
+		return nil, err
+	}
+	if r.exists(id, changed(stored.Code, product.Code)) {
+		return nil, ErrDuplicate
+	}
+	now := time.Now()
+	props, err := storeProps(id, stored.Props, product.Props, now)
+	if err != nil {
+		return nil, err
+	}
+	product.ID = id
+	product.UpdatedAt = now
+	product.Props = props
+	r.products[id] = copyProduct(product)
+	return product, nil
+}
+
+func (r *memoryProductRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.products[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.products, id)
+	return nil
+}
+
+// exists report whether another product than id has the code, an empty code
+// is not checked
+func (r *memoryProductRepository) exists(id, code string) bool {
+	if code == "" {
+		return false
+	}
+	for _, product := range r.products {
+		if product.ID != id && product.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// storeProps is the in-memory counterpart of syncProps, props keeping their
+// key keep their ID and new ones get one
+func storeProps(productID string, stored, wanted []entities.ProductProps, now time.Time) ([]entities.ProductProps, error) {
+	byKey := map[string]entities.ProductProps{}
+	for _, prop := range stored {
+		byKey[prop.Key] = prop
+	}
+	wanted = uniqueProps(wanted)
+	props := make([]entities.ProductProps, 0, len(wanted))
+	for _, prop := range wanted {
+		current, ok := byKey[prop.Key]
+		if !ok {
+			id, err := entities.NewID()
+			if err != nil {
+				return nil, err
+			}
+			current = entities.ProductProps{Key: prop.Key, ProductRef: productID}
+			current.ID, current.CreatedAt = id, now
+		}
+		if !ok || current.Value != prop.Value {
+			current.Value = prop.Value
+			current.UpdatedAt = now
+		}
+		props = append(props, current)
+	}
+	return props, nil
+}
+
+func productRow(p entities.Product) queryspec.Row {
+	return func(column string) interface{} {
+		switch column {
+		case "name":
+			return p.Name
+		case "code":
+			return p.Code
+		case "price":
+			return p.Price
+		}
+		return modelColumn(p.Model, column)
+	}
+}
+
+func copyProduct(p *entities.Product) *entities.Product {
+	product := *p
+	if p.Attr != nil {
+		product.Attr = entities.AttrType{}
+		for k, v := range p.Attr {
+			product.Attr[k] = v
+		}
+	}
+	product.Props = append([]entities.ProductProps{}, p.Props...)
+	return &product
+}
@@ -0,0 +1,177 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"go-example/internal/dto"
+	"go-example/internal/entities"
+	"go-example/internal/queryspec"
+	"go-example/internal/repository"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+var productSchema = queryspec.Schema{
+	"name":  {Column: "name", Kind: queryspec.String, Sortable: true},
+	"price": {Column: "price", Kind: queryspec.Number, Sortable: true},
+}
+
+type MemoryRepositoryTestSuite struct {
+	suite.Suite
+	ctx      context.Context
+	users    repository.UserRepository
+	products repository.ProductRepository
+}
+
+func (s *MemoryRepositoryTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.users = repository.NewMemoryUserRepository()
+	created := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	products := []entities.Product{}
+	for i, name := range []string{"Pen", "Pencil", "Red pen", "Eraser"} {
+		product := entities.Product{Name: name, Code: fmt.Sprintf("P%d", i), Price: uint(100 * (i + 1))}
+		product.ID = fmt.Sprint(i)
+		product.CreatedAt = created.Add(time.Duration(i) * time.Hour)
+		products = append(products, product)
+	}
+	s.products = repository.NewMemoryProductRepository(products...)
+}
+
+func (s *MemoryRepositoryTestSuite) TestUserUniqueness() {
+	user := &entities.User{Username: "utain", Email: "utain@example.com"}
+	s.Require().NoError(s.users.Create(s.ctx, user))
+	s.NotEmpty(user.ID)
+
+	err := s.users.Create(s.ctx, &entities.User{Username: "utain", Email: "other@example.com"})
+	s.ErrorIs(err, repository.ErrDuplicate)
+
+	other := &entities.User{Username: "other", Email: "other@example.com"}
+	s.Require().NoError(s.users.Create(s.ctx, other))
+	_, err = s.users.Update(s.ctx, other.ID, func(u *entities.User) error {
+		u.Email = user.Email
+		return nil
+	})
+	s.ErrorIs(err, repository.ErrDuplicate)
+
+	stored, err := s.users.GetByUsername(s.ctx, "other")
+	s.Require().NoError(err)
+	s.Equal("other@example.com", stored.Email, "failed update must not be stored")
+}
+
+func (s *MemoryRepositoryTestSuite) TestUserDelete() {
+	user := &entities.User{Username: "utain"}
+	s.Require().NoError(s.users.Create(s.ctx, user))
+	s.NoError(s.users.Delete(s.ctx, user.ID))
+	s.ErrorIs(s.users.Delete(s.ctx, user.ID), repository.ErrNotFound)
+	_, err := s.users.Get(s.ctx, user.ID)
+	s.ErrorIs(err, repository.ErrNotFound)
+}
+
+func (s *MemoryRepositoryTestSuite) TestListWithSpec() {
+	spec, err := queryspec.Parse(productSchema, "price>=200", "-price")
+	s.Require().NoError(err)
+
+	products, page, err := s.products.List(s.ctx, dto.Pageable{Limit: 2, Offset: 1}, spec)
+	s.Require().NoError(err)
+	s.EqualValues(3, *page.Total)
+	s.Require().Len(products, 2)
+	s.Equal("Red pen", products[0].Name)
+	s.Equal("Pencil", products[1].Name)
+}
+
+func (s *MemoryRepositoryTestSuite) TestListWithCursor() {
+	cursor := ""
+	names := []string{}
+	for {
+		products, page, err := s.products.List(s.ctx, dto.Pageable{Limit: 3, Cursor: &cursor, Search: "pen"}, nil)
+		s.Require().NoError(err)
+		for _, product := range products {
+			names = append(names, product.Name)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	s.Equal([]string{"Pen", "Pencil", "Red pen"}, names)
+
+	bad := "bad"
+	_, _, err := s.products.List(s.ctx, dto.Pageable{Cursor: &bad}, nil)
+	s.ErrorIs(err, repository.ErrInvalidCursor)
+}
+
+func (s *MemoryRepositoryTestSuite) TestSearch() {
+	hits, page, err := s.products.Search(s.ctx, dto.SearchRequest{Query: "pen"})
+	s.Require().NoError(err)
+	s.EqualValues(2, *page.Total)
+	s.Equal("Pen", hits[0].Name)
+	s.Equal("<mark>Pen</mark> P0", hits[0].Snippet)
+
+	_, page, err = s.products.Search(s.ctx, dto.SearchRequest{Query: "pen", Prefix: true})
+	s.Require().NoError(err)
+	s.EqualValues(3, *page.Total)
+
+	_, _, err = s.products.Search(s.ctx, dto.SearchRequest{Query: "&|"})
+	s.ErrorIs(err, repository.ErrEmptySearch)
+}
+
+func (s *MemoryRepositoryTestSuite) TestSearchSnippetIsEscaped() {
+	product := &entities.Product{Name: `<img src=x onerror="alert(1)">`, Code: "XSS"}
+	s.Require().NoError(s.products.Create(s.ctx, product))
+
+	hits, _, err := s.products.Search(s.ctx, dto.SearchRequest{Query: "xss"})
+	s.Require().NoError(err)
+	s.Require().Len(hits, 1)
+	s.Equal(`&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>XSS</mark>`, hits[0].Snippet)
+}
+
+func (s *MemoryRepositoryTestSuite) TestUpdateProps() {
+	product := &entities.Product{Name: "Pen", Props: []entities.ProductProps{
+		{Key: "color", Value: "red"},
+		{Key: "size", Value: "S"},
+	}}
+	s.Require().NoError(s.products.Create(s.ctx, product))
+	color := product.Props[0].ID
+	s.NotEmpty(color)
+
+	updated, err := s.products.Update(s.ctx, product.ID, func(p *entities.Product) error {
+		p.Props = []entities.ProductProps{{Key: "color", Value: "blue"}, {Key: "weight", Value: "10g"}}
+		return nil
+	})
+	s.Require().NoError(err)
+	s.Require().Len(updated.Props, 2)
+	s.Equal(color, updated.Props[0].ID, "kept key must keep its id")
+	s.Equal("blue", updated.Props[0].Value)
+	s.Equal("weight", updated.Props[1].Key)
+
+	updated.Props[0].Value = "changed"
+	stored, err := s.products.Get(s.ctx, product.ID)
+	s.Require().NoError(err)
+	s.Equal("blue", stored.Props[0].Value, "returned products must be copies")
+}
+
+func (s *MemoryRepositoryTestSuite) TestConcurrentUpdates() {
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.products.Update(s.ctx, "0", func(p *entities.Product) error {
+				p.Price++
+				return nil
+			})
+			s.NoError(err)
+		}()
+	}
+	wg.Wait()
+	product, err := s.products.Get(s.ctx, "0")
+	s.Require().NoError(err)
+	s.EqualValues(150, product.Price)
+}
+
+func TestMemoryRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(MemoryRepositoryTestSuite))
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"go-example/internal/dto"
+	"go-example/internal/entities"
+	"go-example/internal/queryspec"
+	"sync"
+	"time"
+)
+
+type memoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[string]*entities.User
+}
+
+// NewMemoryUserRepository store users in memory, safe for concurrent use.
+// The given users are stored as is, with their IDs and roles
+func NewMemoryUserRepository(users ...entities.User) UserRepository {
+	r := &memoryUserRepository{users: map[string]*entities.User{}}
+	for _, user := range users {
+		user := copyUser(&user)
+		r.users[user.ID] = user
+	}
+	return r
+}
+
+func (r *memoryUserRepository) List(ctx context.Context, pageable dto.Pageable, spec *queryspec.Spec) ([]entities.User, *dto.Page, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	pageable.Normalize()
+	r.mu.RLock()
+	rows := []entities.User{}
+	for _, user := range r.users {
+		if contains(pageable.Search, user.Username, user.Email) {
+			rows = append(rows, *copyUser(user))
+		}
+	}
+	r.mu.RUnlock()
+	return paginateRows(rows, pageable, spec,
+		func(u entities.User) entities.Model { return u.Model },
+		userRow,
+	)
+}
+
+func (r *memoryUserRepository) Get(ctx context.Context, id string) (*entities.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyUser(user), nil
+}
+
+func (r *memoryUserRepository) GetByUsername(ctx context.Context, username string) (*entities.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if user.Username == username {
+			return copyUser(user), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *entities.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.exists("", user.Username, user.Email) {
+		return ErrDuplicate
+	}
+	if user.ID == "" {
+		id, err := entities.NewID()
+		if err != nil {
+			return err
+		}
+		user.ID = id
+	}
+	now := time.Now()
+	user.CreatedAt, user.UpdatedAt = now, now
+	r.users[user.ID] = copyUser(user)
+	return nil
+}
+
+func (r *memoryUserRepository) Update(ctx context.Context, id string, fn func(user *entities.User) error) (*entities.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	user := copyUser(stored)
+	if err := fn(user); err != nil {
+		return nil, err
+	}
+	if r.exists(id, changed(stored.Username, user.Username), changed(stored.Email, user.Email)) {
+		return nil, ErrDuplicate
+	}
+	user.ID = id
+	user.UpdatedAt = time.Now()
+	r.users[id] = copyUser(user)
+	return user, nil
+}
+
+func (r *memoryUserRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
+
+// exists report whether another user than id has the username or the email,
+// empty values are not checked. The caller holds the lock
+func (r *memoryUserRepository) exists(id, username, email string) bool {
+	for _, user := range r.users {
+		if user.ID == id {
+			continue
+		}
+		if username != "" && user.Username == username || email != "" && user.Email == email {
+			return true
+		}
+	}
+	return false
+}
+
+func userRow(u entities.User) queryspec.Row {
+	return func(column string) interface{} {
+		switch column {
+		case "username":
+			return u.Username
+		case "email":
+			return u.Email
+		case "firstname":
+			return u.Firstname
+		case "lastname":
+			return u.Lastname
+		}
+		return modelColumn(u.Model, column)
+	}
+}
+
+func copyUser(u *entities.User) *entities.User {
+	user := *u
+	user.Roles = append([]entities.Role(nil), u.Roles...)
+	return &user
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"go-example/internal/dto"
+	"go-example/internal/queryspec"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// search filter query to rows where one of columns contains text
+func search(query *gorm.DB, text string, columns ...string) *gorm.DB {
+	if text == "" {
+		return query
+	}
+	pattern := queryspec.ContainsPattern(text)
+	conditions := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for i, column := range columns {
+		conditions[i] = column + " ILIKE ?"
+		args[i] = pattern
+	}
+	return query.Where(strings.Join(conditions, " OR "), args...)
+}
+
+// paginate filter and sort query by spec and load the page of pageable into
+// dest, a pointer to a slice of entities. Rows are ordered by (created_at, id)
+// after the orders of spec so pages are stable
+func paginate(query *gorm.DB, pageable dto.Pageable, spec *queryspec.Spec, dest interface{}) (*dto.Page, error) {
+	query = spec.Filter(query)
+	if pageable.Cursor != nil {
+		if spec != nil && len(spec.Orders) > 0 {
+			return nil, ErrSortWithCursor
+		}
+		return paginateCursor(query, pageable, dest)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	err := spec.Order(query).Order("created_at").Order("id").
+		Offset(pageable.Offset).
+		Limit(pageable.Limit).
+		Find(dest).Error
+	if err != nil {
+		return nil, err
+	}
+	return &dto.Page{Total: &total}, nil
+}
+
+// cursor position of the last row of a page
+type cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+func (c cursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	c := &cursor{}
+	if err := json.Unmarshal(b, c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// paginateCursor load the rows after the cursor of pageable, the position is
+// kept by value so inserts and deletes do not shift the following pages
+func paginateCursor(query *gorm.DB, pageable dto.Pageable, dest interface{}) (*dto.Page, error) {
+	if *pageable.Cursor != "" {
+		after, err := decodeCursor(*pageable.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("(created_at, id) > (?, ?)", after.CreatedAt, after.ID)
+	}
+	// one more row tell whether a next page exists
+	err := query.Order("created_at").Order("id").
+		Limit(pageable.Limit + 1).
+		Find(dest).Error
+	if err != nil {
+		return nil, err
+	}
+
+	page := &dto.Page{}
+	rows := reflect.ValueOf(dest).Elem()
+	if rows.Len() > pageable.Limit {
+		rows.Set(rows.Slice(0, pageable.Limit))
+		last := rows.Index(pageable.Limit - 1)
+		page.NextCursor = cursor{
+			CreatedAt: last.FieldByName("CreatedAt").Interface().(time.Time),
+			ID:        last.FieldByName("ID").String(),
+		}.encode()
+	}
+	return page, nil
+}
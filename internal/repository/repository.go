@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-example/internal/dto"
+	"go-example/internal/entities"
+	"go-example/internal/queryspec"
+)
+
+var (
+	// ErrNotFound row does not exist or was deleted
+	ErrNotFound = errors.New("not found")
+	// ErrDuplicate natural key of the row is already used by another row
+	ErrDuplicate = errors.New("duplicate")
+	// ErrInvalidCursor cursor was not issued by a previous page
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrSortWithCursor cursor pagination only follow the creation order
+	ErrSortWithCursor = errors.New("sort is not supported with cursor pagination")
+)
+
+// UserRepository storage of users, usernames and emails are unique. Methods
+// fail with an error wrapping the error of ctx when ctx is done first
+type UserRepository interface {
+	// List return a page of users matching pageable.Search by username or
+	// email and the conditions of spec
+	List(ctx context.Context, pageable dto.Pageable, spec *queryspec.Spec) ([]entities.User, *dto.Page, error)
+	Get(ctx context.Context, id string) (*entities.User, error)
+	// GetByUsername return the user and its roles
+	GetByUsername(ctx context.Context, username string) (*entities.User, error)
+	// Create store user with a generated ID, ErrDuplicate when the username
+	// or the email is used
+	Create(ctx context.Context, user *entities.User) error
+	// Update apply fn to the stored user and save it atomically, ErrDuplicate
+	// when the new email is used
+	Update(ctx context.Context, id string, fn func(user *entities.User) error) (*entities.User, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// ProductRepository storage of products and their props, product codes are
+// unique. Methods fail with an error wrapping the error of ctx when ctx is
+// done first
+type ProductRepository interface {
+	// List return a page of products matching pageable.Search by name or
+	// code and the conditions of spec
+	List(ctx context.Context, pageable dto.Pageable, spec *queryspec.Spec) ([]entities.Product, *dto.Page, error)
+	// Search return a page of the products matching every word of
+	// req.Query, best ranked first
+	Search(ctx context.Context, req dto.SearchRequest) ([]ProductSearchHit, *dto.Page, error)
+	// Get return the product and its props
+	Get(ctx context.Context, id string) (*entities.Product, error)
+	// Create store product and its props with generated IDs, ErrDuplicate
+	// when the code is used
+	Create(ctx context.Context, product *entities.Product) error
+	// Update apply fn to the stored product and save it atomically, the props
+	// left by fn replace the stored ones by key. ErrDuplicate when the new
+	// code is used
+	Update(ctx context.Context, id string, fn func(product *entities.Product) error) (*entities.Product, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// contextError wrap err with the error of ctx once ctx is done, drivers
+// report interrupted queries in their own words so callers could not tell a
+// cancelled request from a failure
+func contextError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil || errors.Is(err, ctx.Err()) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ctx.Err(), err)
+}
+
+// ProductSearchHit product matching a search, Snippet is an html escaped
+// extract of its name, code and prop values with the matching words
+// highlighted by <mark>
+type ProductSearchHit struct {
+	entities.Product
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+} // @name ProductSearchHit
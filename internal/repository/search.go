@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// ErrEmptySearch search text has no word to look for
+var ErrEmptySearch = errors.New("search requires at least one word")
+
+// headlineOptions highlight the matching words of the snippet, the source
+// text is html escaped first so the snippet is safe to render as markup
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5"
+
+const (
+	productSearchCount = `SELECT count(*) FROM products, to_tsquery(?, ?) query
+WHERE products.search_vector @@ query AND products.deleted_at IS NULL`
+	productSearchQuery = `SELECT products.*,
+	ts_rank(products.search_vector, query) AS rank,
+	ts_headline(?, replace(replace(replace(replace(replace(
+		concat_ws(' ', products.name, products.code,
+			(SELECT string_agg(pp.value, ' ') FROM product_props pp
+			WHERE pp.product_ref = products.id AND pp.deleted_at IS NULL)),
+		'&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&#34;'), '''', '&#39;'), query, ?) AS snippet
+FROM products, to_tsquery(?, ?) query
+WHERE products.search_vector @@ query AND products.deleted_at IS NULL
+ORDER BY rank DESC, products.created_at, products.id
+LIMIT ? OFFSET ?`
+)
+
+// searchWords split text on anything but letters and digits, so operators
+// of the tsquery syntax never reach postgres
+func searchWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// toTSQuery build a tsquery matching every word of text, with prefix every
+// word also matches longer words
+func toTSQuery(text string, prefix bool) (string, error) {
+	words := searchWords(text)
+	if len(words) == 0 {
+		return "", ErrEmptySearch
+	}
+	if prefix {
+		for i := range words {
+			words[i] += ":*"
+		}
+	}
+	return strings.Join(words, " & "), nil
+}
@@ -0,0 +1,66 @@
+package route
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/chi/v5"
+)
+
+type contextKey struct{}
+
+// holder of the pattern set by the router which served the request, it is
+// shared by every copy of the request context
+type holder struct {
+	mu      sync.Mutex
+	pattern string
+}
+
+// Track return a request with a context able to remember the route pattern
+// of the request, middleware call it before serving and Pattern after
+func Track(r *http.Request) *http.Request {
+	if _, ok := r.Context().Value(contextKey{}).(*holder); ok {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), contextKey{}, &holder{}))
+}
+
+// Set the route pattern of the request of ctx, routers mounted under chi call
+// it as chi only knows their mount point
+func Set(ctx context.Context, pattern string) {
+	if h, ok := ctx.Value(contextKey{}).(*holder); ok {
+		h.mu.Lock()
+		h.pattern = pattern
+		h.mu.Unlock()
+	}
+}
+
+// Pattern return the route pattern which served r, e.g. /api/v1/products/:id,
+// or an empty string when no route matched. The pattern given to Set wins
+// over the one of chi
+func Pattern(r *http.Request) string {
+	if h, ok := r.Context().Value(contextKey{}).(*holder); ok {
+		h.mu.Lock()
+		pattern := h.pattern
+		h.mu.Unlock()
+		if pattern != "" {
+			return pattern
+		}
+	}
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
+
+// GinPattern Set the pattern of the matched gin route
+func GinPattern() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if pattern := ctx.FullPath(); pattern != "" {
+			Set(ctx.Request.Context(), pattern)
+		}
+		ctx.Next()
+	}
+}
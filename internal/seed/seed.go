@@ -0,0 +1,276 @@
+package seed
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go-example/internal/entities"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Fixtures rows to seed, JSON files are read as YAML
+type Fixtures struct {
+	Roles    []RoleFixture    `yaml:"roles"`
+	Users    []UserFixture    `yaml:"users"`
+	Products []ProductFixture `yaml:"products"`
+}
+
+// RoleFixture role identified by Name
+type RoleFixture struct {
+	Name        string   `yaml:"name"`
+	Permissions []string `yaml:"permissions"`
+}
+
+// UserFixture user identified by Username, Roles are role names
+type UserFixture struct {
+	Username  string   `yaml:"username"`
+	Email     string   `yaml:"email"`
+	Password  string   `yaml:"password"`
+	Firstname string   `yaml:"firstname"`
+	Lastname  string   `yaml:"lastname"`
+	Roles     []string `yaml:"roles"`
+}
+
+// ProductFixture product identified by Code, Props replace the props of the
+// product
+type ProductFixture struct {
+	Name  string            `yaml:"name"`
+	Code  string            `yaml:"code"`
+	Price uint              `yaml:"price"`
+	Attr  map[string]string `yaml:"attr"`
+	Props []PropFixture     `yaml:"props"`
+}
+
+// PropFixture property of a product identified by Key
+type PropFixture struct {
+	Key   string `yaml:"key"`
+	Value string `yaml:"value"`
+}
+
+// Result number of rows seeded by kind
+type Result struct {
+	Roles    int
+	Users    int
+	Products int
+}
+
+// resetTables are truncated by a reset, every seeded table and the tables
+// referencing them
+var resetTables = []string{"user_roles", "product_props", "products", "users", "roles"}
+
+// Load merge the .yaml, .yml and .json fixtures of fsys in file name order
+func Load(fsys fs.FS) (*Fixtures, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	fixtures := &Fixtures{}
+	for _, entry := range entries {
+		switch path.Ext(entry.Name()) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		var file Fixtures
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("fixture %s: %w", entry.Name(), err)
+		}
+		fixtures.Roles = append(fixtures.Roles, file.Roles...)
+		fixtures.Users = append(fixtures.Users, file.Users...)
+		fixtures.Products = append(fixtures.Products, file.Products...)
+	}
+	return fixtures, nil
+}
+
+// Seed upsert the fixtures by natural key in one transaction, soft deleted
+// rows are restored. With reset every seeded table is truncated first
+func Seed(db *gorm.DB, fixtures *Fixtures, reset bool) (Result, error) {
+	var result Result
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if reset {
+			if err := tx.Exec("TRUNCATE " + strings.Join(resetTables, ", ") + " CASCADE").Error; err != nil {
+				return fmt.Errorf("failed to reset tables: %w", err)
+			}
+		}
+		roles := map[string]entities.Role{}
+		for _, fixture := range fixtures.Roles {
+			role, err := upsertRole(tx, fixture)
+			if err != nil {
+				return fmt.Errorf("role %q: %w", fixture.Name, err)
+			}
+			roles[role.Name] = *role
+			result.Roles++
+		}
+		for _, fixture := range fixtures.Users {
+			if err := upsertUser(tx, fixture, roles); err != nil {
+				return fmt.Errorf("user %q: %w", fixture.Username, err)
+			}
+			result.Users++
+		}
+		for _, fixture := range fixtures.Products {
+			if err := upsertProduct(tx, fixture); err != nil {
+				return fmt.Errorf("product %q: %w", fixture.Code, err)
+			}
+			result.Products++
+		}
+		return nil
+	})
+	return result, err
+}
+
+func upsertRole(tx *gorm.DB, fixture RoleFixture) (*entities.Role, error) {
+	if fixture.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	role := &entities.Role{
+		Name:        fixture.Name,
+		Permissions: entities.PermissionsType(fixture.Permissions),
+	}
+	id, err := upsert(tx, "roles", role, "name", fixture.Name, "permissions")
+	if err != nil {
+		return nil, err
+	}
+	role.ID = id
+	return role, nil
+}
+
+func upsertUser(tx *gorm.DB, fixture UserFixture, roles map[string]entities.Role) error {
+	if fixture.Username == "" {
+		return errors.New("username is required")
+	}
+	user := &entities.User{
+		Username:  fixture.Username,
+		Email:     fixture.Email,
+		Firstname: fixture.Firstname,
+		Lastname:  fixture.Lastname,
+	}
+	columns := []string{"email", "firstname", "lastname"}
+	if fixture.Password != "" {
+		// keep the stored hash when the password did not change
+		if err := tx.Table("users").Select("password").
+			Where("username = ? AND deleted_at IS NULL", fixture.Username).
+			Scan(&user.Password).Error; err != nil {
+			return err
+		}
+		if !user.CheckPassword(fixture.Password) {
+			if err := user.SetPassword(fixture.Password); err != nil {
+				return err
+			}
+			columns = append(columns, "password")
+		}
+	}
+	id, err := upsert(tx, "users", user, "username", fixture.Username, columns...)
+	if err != nil {
+		return err
+	}
+	user.ID = id
+	if fixture.Password == "" {
+		var stored string
+		if err := tx.Table("users").Select("password").Where("id = ?", id).Scan(&stored).Error; err != nil {
+			return err
+		}
+		if stored == "" {
+			return errors.New("password is required")
+		}
+	}
+
+	userRoles := make([]entities.Role, 0, len(fixture.Roles))
+	for _, name := range fixture.Roles {
+		role, ok := roles[name]
+		if !ok {
+			if err := tx.Where("name = ?", name).Take(&role).Error; err != nil {
+				return fmt.Errorf("unknown role %q: %w", name, err)
+			}
+		}
+		userRoles = append(userRoles, role)
+	}
+	return tx.Model(user).Association("Roles").Replace(userRoles)
+}
+
+func upsertProduct(tx *gorm.DB, fixture ProductFixture) error {
+	if fixture.Code == "" {
+		return errors.New("code is required")
+	}
+	product := &entities.Product{
+		Name:  fixture.Name,
+		Code:  fixture.Code,
+		Price: fixture.Price,
+		Attr:  entities.AttrType(fixture.Attr),
+	}
+	id, err := upsert(tx, "products", product, "code", fixture.Code, "name", "price", "attr")
+	if err != nil {
+		return err
+	}
+	product.ID = id
+
+	// props are replaced: update or create by key, remove the others
+	var existing []entities.ProductProps
+	if err := tx.Where("product_ref = ?", product.ID).Find(&existing).Error; err != nil {
+		return err
+	}
+	byKey := map[string]*entities.ProductProps{}
+	for i := range existing {
+		byKey[existing[i].Key] = &existing[i]
+	}
+	for _, fixtureProp := range fixture.Props {
+		prop, ok := byKey[fixtureProp.Key]
+		if !ok {
+			prop = &entities.ProductProps{Key: fixtureProp.Key, ProductRef: product.ID}
+		} else if prop.Value == fixtureProp.Value {
+			delete(byKey, fixtureProp.Key)
+			continue
+		}
+		prop.Value = fixtureProp.Value
+		if err := tx.Save(prop).Error; err != nil {
+			return err
+		}
+		delete(byKey, fixtureProp.Key)
+	}
+	for _, prop := range byKey {
+		if err := tx.Delete(prop).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upsert insert model or update the columns of the live row of table with
+// the same key, the unique index on key makes concurrent seeds update one
+// row instead of racing. The last soft deleted row is restored first so it
+// keeps its id and relations. It returns the id of the stored row
+func upsert(tx *gorm.DB, table string, model interface{}, key string, value interface{}, columns ...string) (string, error) {
+	restore := "UPDATE " + table + " SET deleted_at = NULL WHERE id = (SELECT id FROM " + table +
+		" WHERE " + key + " = ? AND deleted_at IS NOT NULL ORDER BY deleted_at DESC LIMIT 1)" +
+		" AND NOT EXISTS (SELECT 1 FROM " + table + " WHERE " + key + " = ? AND deleted_at IS NULL)"
+	if err := tx.Exec(restore, value, value).Error; err != nil {
+		return "", err
+	}
+	live := clause.Eq{Column: clause.Column{Name: "deleted_at"}, Value: nil}
+	err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: key}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{live}},
+		DoUpdates:   clause.AssignmentColumns(append(columns, "deleted_at", "updated_at")),
+	}).Create(model).Error
+	if err != nil {
+		return "", err
+	}
+	// on conflict the row keeps its id, not the one generated for model
+	var id string
+	err = tx.Table(table).Select("id").Where(key+" = ? AND deleted_at IS NULL", value).Scan(&id).Error
+	return id, err
+}
@@ -0,0 +1,96 @@
+package seed_test
+
+import (
+	"go-example/internal/seed"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestLoadMergeFixtures(t *testing.T) {
+	fixtures, err := seed.Load(fstest.MapFS{
+		"1-users.yaml":    {Data: []byte("users:\n  - username: admin\n    password: secret\n    roles: [admin]\n")},
+		"2-products.json": {Data: []byte(`{"products": [{"code": "PEN", "props": [{"key": "ink", "value": "gel"}]}]}`)},
+		"empty.yml":       {Data: []byte("")},
+		"README.md":       {Data: []byte("ignored")},
+	})
+	require.NoError(t, err)
+	require.Len(t, fixtures.Users, 1)
+	require.Equal(t, []string{"admin"}, fixtures.Users[0].Roles)
+	require.Len(t, fixtures.Products, 1)
+	require.Equal(t, "gel", fixtures.Products[0].Props[0].Value)
+}
+
+func TestLoadRejectUnknownField(t *testing.T) {
+	_, err := seed.Load(fstest.MapFS{
+		"users.yaml": {Data: []byte("users:\n  - usrname: admin\n")},
+	})
+	require.ErrorContains(t, err, "users.yaml")
+}
+
+func TestLoadLocalFixtures(t *testing.T) {
+	fixtures, err := seed.Load(os.DirFS("../../config/fixtures/local"))
+	require.NoError(t, err)
+	require.NotEmpty(t, fixtures.Users)
+	require.NotEmpty(t, fixtures.Products)
+}
+
+func TestSeedResetAndCreateProduct(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}))
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("TRUNCATE user_roles, product_props, products, users, roles CASCADE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE products SET deleted_at = NULL`).
+		WithArgs("PEN", "PEN").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO "products" .* ON CONFLICT \("code"\) +WHERE "deleted_at" IS NULL +DO UPDATE SET`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// the row of a concurrent seed keeps its id
+	mock.ExpectQuery(`SELECT id FROM "products" WHERE code = \$1 AND deleted_at IS NULL`).
+		WithArgs("PEN").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("stored"))
+	mock.ExpectQuery(`SELECT \* FROM "product_props" WHERE product_ref = \$1`).
+		WithArgs("stored").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(`INSERT INTO "product_props"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	result, err := seed.Seed(db, &seed.Fixtures{
+		Products: []seed.ProductFixture{{Code: "PEN", Name: "Pen", Props: []seed.PropFixture{{Key: "ink", Value: "gel"}}}},
+	}, true)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Products)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSeedRequirePasswordOfNewUser(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}))
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE users SET deleted_at = NULL`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO "users" .* ON CONFLICT \("username"\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT id FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+	mock.ExpectQuery(`SELECT password FROM "users" WHERE id = \$1`).
+		WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"password"}).AddRow(""))
+	mock.ExpectRollback()
+
+	_, err = seed.Seed(db, &seed.Fixtures{Users: []seed.UserFixture{{Username: "admin"}}}, false)
+	require.ErrorContains(t, err, "password is required")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
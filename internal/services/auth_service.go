@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-example/internal/auth"
+	"go-example/internal/dto"
+	"go-example/internal/entities"
+	"go-example/internal/repository"
+	"math"
+	"time"
+)
+
+// ErrInvalidCredentials unknown username or wrong password
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// dummyUser hash compared when the username is unknown, so the response
+// time does not reveal which usernames exist
+var dummyUser = func() *entities.User {
+	u := &entities.User{}
+	u.SetPassword("dummy-password")
+	return u
+}()
+
+// AuthService authenticate users
+type AuthService interface {
+	Login(ctx context.Context, req dto.LoginRequest) (*dto.TokenReply, error)
+}
+
+type authService struct {
+	users  repository.UserRepository
+	tokens *auth.Tokens
+}
+
+// NewAuthService create authService
+func NewAuthService(users repository.UserRepository, tokens *auth.Tokens) AuthService {
+	return &authService{users: users, tokens: tokens}
+}
+
+// Login verify the credentials and issue an access token
+func (a authService) Login(ctx context.Context, req dto.LoginRequest) (*dto.TokenReply, error) {
+	user, err := a.users.GetByUsername(ctx, req.Username)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			dummyUser.CheckPassword(req.Password)
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if !user.CheckPassword(req.Password) {
+		return nil, ErrInvalidCredentials
+	}
+	token, expiresAt, err := a.tokens.Issue(auth.Principal{
+		ID:          user.ID,
+		Username:    user.Username,
+		Roles:       user.RoleNames(),
+		Permissions: user.Permissions(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dto.TokenReply{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(math.Ceil(time.Until(expiresAt).Seconds())),
+	}, nil
+}
@@ -0,0 +1,13 @@
+package services
+
+import (
+	"context"
+	"errors"
+)
+
+// isContextError report whether err comes from a cancelled or expired
+// context. Such errors are returned as is so the api can tell them from
+// failures of the storage
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
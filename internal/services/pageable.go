@@ -0,0 +1,34 @@
+package services
+
+import (
+	"go-example/internal/queryspec"
+	"go-example/internal/repository"
+)
+
+var (
+	// ErrInvalidCursor cursor was not issued by a previous page
+	ErrInvalidCursor = repository.ErrInvalidCursor
+	// ErrSortWithCursor cursor pagination only follow the creation order
+	ErrSortWithCursor = repository.ErrSortWithCursor
+)
+
+// ProductQuerySchema fields of products clients can filter and sort on
+var ProductQuerySchema = queryspec.Schema{
+	"id":        {Column: "id", Kind: queryspec.String},
+	"name":      {Column: "name", Kind: queryspec.String, Sortable: true},
+	"code":      {Column: "code", Kind: queryspec.String, Sortable: true},
+	"price":     {Column: "price", Kind: queryspec.Number, Sortable: true},
+	"createdAt": {Column: "created_at", Kind: queryspec.Time, Sortable: true},
+	"updatedAt": {Column: "updated_at", Kind: queryspec.Time, Sortable: true},
+}
+
+// UserQuerySchema fields of users clients can filter and sort on
+var UserQuerySchema = queryspec.Schema{
+	"id":        {Column: "id", Kind: queryspec.String},
+	"username":  {Column: "username", Kind: queryspec.String, Sortable: true},
+	"email":     {Column: "email", Kind: queryspec.String, Sortable: true},
+	"firstname": {Column: "firstname", Kind: queryspec.String, Sortable: true},
+	"lastname":  {Column: "lastname", Kind: queryspec.String, Sortable: true},
+	"createdAt": {Column: "created_at", Kind: queryspec.Time, Sortable: true},
+	"updatedAt": {Column: "updated_at", Kind: queryspec.Time, Sortable: true},
+}
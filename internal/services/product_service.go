@@ -1,55 +1,197 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"go-example/internal/dto"
 	"go-example/internal/entities"
 	"go-example/internal/log"
+	"go-example/internal/queryspec"
+	"go-example/internal/repository"
+)
 
-	"gorm.io/gorm"
+var (
+	// ErrProductNotFound product does not exist or was deleted
+	ErrProductNotFound = errors.New("product not found")
+	// ErrProductExists code is already used by another product
+	ErrProductExists = errors.New("product code already exists")
+	// ErrEmptySearch search text has no word to look for
+	ErrEmptySearch = repository.ErrEmptySearch
 )
 
 // ProductService api controller of produces
 type ProductService interface {
-	FindAll(pageable dto.Pageable) (*[]entities.Product, error)
-	GetProduct(id string) (*entities.Product, error)
-	DeleteProduct(id string) error
+	FindAll(ctx context.Context, pageable dto.Pageable, spec *queryspec.Spec) (*[]entities.Product, *dto.Page, error)
+	SearchProducts(ctx context.Context, req dto.SearchRequest) (*[]repository.ProductSearchHit, *dto.Page, error)
+	GetProduct(ctx context.Context, id string) (*entities.Product, error)
+	CreateProduct(ctx context.Context, req dto.ProductRequest) (*entities.Product, error)
+	UpdateProduct(ctx context.Context, id string, req dto.ProductRequest) (*entities.Product, error)
+	PatchProduct(ctx context.Context, id string, req dto.PatchProductRequest) (*entities.Product, error)
+	DeleteProduct(ctx context.Context, id string) error
 }
 
 type productService struct {
-	db *gorm.DB
+	products repository.ProductRepository
 }
 
 // NewProductService get product service instance
-func NewProductService(db *gorm.DB) ProductService {
-	return &productService{db}
+func NewProductService(products repository.ProductRepository) ProductService {
+	return &productService{products}
+}
+
+// FindAll return a page of products matching pageable.Search by name or
+// code, and the page information of matching products. It reads from a
+// replica unless ctx requires read your writes
+func (p productService) FindAll(ctx context.Context, pageable dto.Pageable, spec *queryspec.Spec) (*[]entities.Product, *dto.Page, error) {
+	products, page, err := p.products.List(ctx, pageable, spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &products, page, nil
+}
+
+// SearchProducts return a page of the products matching every word of
+// req.Query, best ranked first. It reads from a replica unless ctx requires
+// read your writes
+func (p productService) SearchProducts(ctx context.Context, req dto.SearchRequest) (*[]repository.ProductSearchHit, *dto.Page, error) {
+	hits, page, err := p.products.Search(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &hits, page, nil
 }
 
-func (p productService) FindAll(pageable dto.Pageable) (*[]entities.Product, error) {
-	// do stuff
-	products := new([]entities.Product)
-	p.db.Find(products)
-	return products, nil
+// GetProduct return the product and its props, it reads from a replica
+// unless ctx requires read your writes
+func (p productService) GetProduct(ctx context.Context, id string) (*entities.Product, error) {
+	product, err := p.products.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	return product, nil
+}
+
+// CreateProduct insert the product and its props in one transaction
+func (p productService) CreateProduct(ctx context.Context, req dto.ProductRequest) (*entities.Product, error) {
+	product := &entities.Product{
+		Name:  req.Name,
+		Code:  req.Code,
+		Price: req.Price,
+		Attr:  entities.AttrType(req.Attr),
+		Props: propsOf(req.Props),
+	}
+	if err := p.products.Create(ctx, product); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDuplicate):
+			return nil, ErrProductExists
+		case isContextError(err):
+			return nil, err
+		}
+		log.Error("fail to create product:" + err.Error())
+		return nil, err
+	}
+	return product, nil
 }
 
-func (p productService) GetProduct(id string) (*entities.Product, error) {
-	// do stuff
-	product := &entities.Product{}
-	if err := p.db.Preload("Props").First(&product, entities.Product{Model: entities.Model{ID: id}}).Error; err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, errors.New("product not found")
+// UpdateProduct replace the product, props missing from req are deleted
+func (p productService) UpdateProduct(ctx context.Context, id string, req dto.ProductRequest) (*entities.Product, error) {
+	product, err := p.products.Update(ctx, id, func(product *entities.Product) error {
+		product.Name = req.Name
+		product.Code = req.Code
+		product.Price = req.Price
+		product.Attr = entities.AttrType(req.Attr)
+		product.Props = propsOf(req.Props)
+		return nil
+	})
+	if err != nil {
+		return nil, p.updateError("update", err)
 	}
 	return product, nil
 }
 
-func (p productService) DeleteProduct(id string) error {
+// PatchProduct update the fields set in req and merge its props by key
+func (p productService) PatchProduct(ctx context.Context, id string, req dto.PatchProductRequest) (*entities.Product, error) {
+	product, err := p.products.Update(ctx, id, func(product *entities.Product) error {
+		if req.Name != nil {
+			product.Name = *req.Name
+		}
+		if req.Code != nil {
+			product.Code = *req.Code
+		}
+		if req.Price != nil {
+			product.Price = *req.Price
+		}
+		if req.Attr != nil {
+			product.Attr = entities.AttrType(req.Attr)
+		}
+		product.Props = mergeProps(product.Props, req.Props)
+		return nil
+	})
+	if err != nil {
+		return nil, p.updateError("patch", err)
+	}
+	return product, nil
+}
+
+// DeleteProduct soft delete the product
+func (p productService) DeleteProduct(ctx context.Context, id string) error {
 	log.Info("Delete product id=" + id)
-	tx := p.db.Begin()
-	rs := tx.Delete(&entities.Product{Model: entities.Model{ID: id}}).Where("id=?", id)
-	if rs.Error != nil {
-		log.Error("fail to delete product:" + rs.Error.Error())
-		tx.Rollback()
+	if err := p.products.Delete(ctx, id); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrProductNotFound
+		case isContextError(err):
+			return err
+		}
+		log.Error("fail to delete product:" + err.Error())
 		return errors.New("can't delete product")
 	}
-	tx.Commit()
 	return nil
 }
+
+func (p productService) updateError(action string, err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return ErrProductNotFound
+	case errors.Is(err, repository.ErrDuplicate):
+		return ErrProductExists
+	case isContextError(err):
+		return err
+	}
+	log.Error("fail to " + action + " product:" + err.Error())
+	return err
+}
+
+func propsOf(reqProps []dto.ProductPropsRequest) []entities.ProductProps {
+	props := make([]entities.ProductProps, len(reqProps))
+	for i, prop := range reqProps {
+		props[i] = entities.ProductProps{Key: prop.Key, Value: prop.Value}
+	}
+	return props
+}
+
+// mergeProps apply the changes of reqProps to props by key, a nil value
+// removes the prop
+func mergeProps(props []entities.ProductProps, reqProps []dto.PatchProductPropsRequest) []entities.ProductProps {
+	for _, change := range reqProps {
+		index := -1
+		for i, prop := range props {
+			if prop.Key == change.Key {
+				index = i
+				break
+			}
+		}
+		switch {
+		case change.Value == nil && index >= 0:
+			props = append(props[:index], props[index+1:]...)
+		case change.Value != nil && index >= 0:
+			props[index].Value = *change.Value
+		case change.Value != nil:
+			props = append(props, entities.ProductProps{Key: change.Key, Value: *change.Value})
+		}
+	}
+	return props
+}
@@ -0,0 +1,90 @@
+package services_test
+
+import (
+	"context"
+	"go-example/internal/dto"
+	"go-example/internal/entities"
+	"go-example/internal/repository"
+	"go-example/internal/services"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ProductServiceTestSuite struct {
+	suite.Suite
+	service services.ProductService
+	product *entities.Product
+}
+
+func (s *ProductServiceTestSuite) SetupTest() {
+	s.service = services.NewProductService(repository.NewMemoryProductRepository())
+	var err error
+	s.product, err = s.service.CreateProduct(context.Background(), dto.ProductRequest{
+		Name: "Pen",
+		Code: "P1",
+		Props: []dto.ProductPropsRequest{
+			{Key: "color", Value: "red"},
+			{Key: "size", Value: "S"},
+		},
+	})
+	s.Require().NoError(err)
+}
+
+func (s *ProductServiceTestSuite) TestUpdateReplaceProps() {
+	product, err := s.service.UpdateProduct(context.Background(), s.product.ID, dto.ProductRequest{
+		Name:  "Pen",
+		Code:  "P1",
+		Props: []dto.ProductPropsRequest{{Key: "size", Value: "M"}},
+	})
+	s.Require().NoError(err)
+	s.Require().Len(product.Props, 1)
+	s.Equal("M", product.Props[0].Value)
+}
+
+func (s *ProductServiceTestSuite) TestPatchMergeProps() {
+	blue := "blue"
+	name := "Blue pen"
+	product, err := s.service.PatchProduct(context.Background(), s.product.ID, dto.PatchProductRequest{
+		Name: &name,
+		Props: []dto.PatchProductPropsRequest{
+			{Key: "color", Value: &blue},
+			{Key: "size"},
+			{Key: "weight", Value: &blue},
+		},
+	})
+	s.Require().NoError(err)
+	s.Equal("Blue pen", product.Name)
+	s.Equal("P1", product.Code)
+	s.Require().Len(product.Props, 2)
+	s.Equal("color", product.Props[0].Key)
+	s.Equal("blue", product.Props[0].Value)
+	s.Equal("weight", product.Props[1].Key)
+}
+
+func (s *ProductServiceTestSuite) TestDuplicateCode() {
+	_, err := s.service.CreateProduct(context.Background(), dto.ProductRequest{Name: "Other pen", Code: "P1"})
+	s.ErrorIs(err, services.ErrProductExists)
+
+	other, err := s.service.CreateProduct(context.Background(), dto.ProductRequest{Name: "Pencil", Code: "P2"})
+	s.Require().NoError(err)
+	code := "P1"
+	_, err = s.service.PatchProduct(context.Background(), other.ID, dto.PatchProductRequest{Code: &code})
+	s.ErrorIs(err, services.ErrProductExists)
+
+	// keeping its own code is not a conflict
+	_, err = s.service.UpdateProduct(context.Background(), s.product.ID, dto.ProductRequest{Name: "Red pen", Code: "P1"})
+	s.NoError(err)
+}
+
+func (s *ProductServiceTestSuite) TestProductNotFound() {
+	_, err := s.service.GetProduct(context.Background(), "x")
+	s.ErrorIs(err, services.ErrProductNotFound)
+	_, err = s.service.UpdateProduct(context.Background(), "x", dto.ProductRequest{Name: "Pen", Code: "P1"})
+	s.ErrorIs(err, services.ErrProductNotFound)
+	s.ErrorIs(s.service.DeleteProduct(context.Background(), "x"), services.ErrProductNotFound)
+}
+
+func TestProductServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ProductServiceTestSuite))
+}
@@ -1,44 +1,137 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"go-example/internal/dto"
 	"go-example/internal/entities"
+	"go-example/internal/log"
+	"go-example/internal/queryspec"
+	"go-example/internal/repository"
+)
 
-	"gorm.io/gorm"
+var (
+	// ErrUserNotFound user does not exist or was deleted
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUserExists username or email is already used by another user
+	ErrUserExists = errors.New("username or email already exists")
 )
 
 // NewUserService create userService
-func NewUserService(db *gorm.DB) UserService {
-	return &userService{db}
+func NewUserService(users repository.UserRepository) UserService {
+	return &userService{users}
 }
 
 //UserService interface
 type UserService interface {
-	GetAllUser(page dto.Pageable) (*[]entities.User, error)
-	GetUser(id string) (*entities.User, error)
+	GetAllUser(ctx context.Context, page dto.Pageable, spec *queryspec.Spec) (*[]entities.User, *dto.Page, error)
+	GetUser(ctx context.Context, id string) (*entities.User, error)
+	CreateUser(ctx context.Context, req dto.CreateUserRequest) (*entities.User, error)
+	UpdateUser(ctx context.Context, id string, req dto.UpdateUserRequest) (*entities.User, error)
+	DeleteUser(ctx context.Context, id string) error
 }
 
 // userService is a service private
 type userService struct {
-	db *gorm.DB
+	users repository.UserRepository
 }
 
-// GetAllUser return all User
-func (p userService) GetAllUser(pageable dto.Pageable) (*[]entities.User, error) {
-	users := new([]entities.User)
-	p.db.Find(users)
-	return users, nil
+// GetAllUser return a page of users matching pageable.Search by username or
+// email, and the page information of matching users. It reads from a replica
+// unless ctx requires read your writes
+func (p userService) GetAllUser(ctx context.Context, pageable dto.Pageable, spec *queryspec.Spec) (*[]entities.User, *dto.Page, error) {
+	users, page, err := p.users.List(ctx, pageable, spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &users, page, nil
 }
 
-// GetUser return only one User
-func (p userService) GetUser(id string) (*entities.User, error) {
-	user := &entities.User{}
-	if err := p.db.First(user, &entities.User{Model: entities.Model{ID: id}}).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+// GetUser return only one User, it reads from a replica unless ctx requires
+// read your writes
+func (p userService) GetUser(ctx context.Context, id string) (*entities.User, error) {
+	user, err := p.users.Get(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrUserNotFound
+		case isContextError(err):
+			return nil, err
 		}
 		return nil, errors.New("unknown error")
 	}
 	return user, nil
 }
+
+// CreateUser store a new user with a hashed password
+func (p userService) CreateUser(ctx context.Context, req dto.CreateUserRequest) (*entities.User, error) {
+	user := &entities.User{
+		Username:  req.Username,
+		Email:     req.Email,
+		Firstname: req.Firstname,
+		Lastname:  req.Lastname,
+	}
+	if err := user.SetPassword(req.Password); err != nil {
+		return nil, err
+	}
+	if err := p.users.Create(ctx, user); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDuplicate):
+			return nil, ErrUserExists
+		case isContextError(err):
+			return nil, err
+		}
+		log.Error("fail to create user:" + err.Error())
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateUser change the fields set in req, a new password is hashed
+func (p userService) UpdateUser(ctx context.Context, id string, req dto.UpdateUserRequest) (*entities.User, error) {
+	user, err := p.users.Update(ctx, id, func(user *entities.User) error {
+		if req.Email != nil {
+			user.Email = *req.Email
+		}
+		if req.Password != nil {
+			if err := user.SetPassword(*req.Password); err != nil {
+				return err
+			}
+		}
+		if req.Firstname != nil {
+			user.Firstname = *req.Firstname
+		}
+		if req.Lastname != nil {
+			user.Lastname = *req.Lastname
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return nil, ErrUserNotFound
+	case errors.Is(err, repository.ErrDuplicate):
+		return nil, ErrUserExists
+	case isContextError(err):
+		return nil, err
+	case err != nil:
+		log.Error("fail to update user:" + err.Error())
+		return nil, err
+	}
+	return user, nil
+}
+
+// DeleteUser soft delete the user
+func (p userService) DeleteUser(ctx context.Context, id string) error {
+	log.Info("Delete user id=" + id)
+	if err := p.users.Delete(ctx, id); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrUserNotFound
+		case isContextError(err):
+			return err
+		}
+		log.Error("fail to delete user:" + err.Error())
+		return errors.New("can't delete user")
+	}
+	return nil
+}
@@ -1,9 +1,10 @@
 package services_test
 
 import (
+	"context"
 	"database/sql"
 
-	"go-example/internal/entities"
+	"go-example/internal/repository"
 	"go-example/internal/services"
 	"regexp"
 	"testing"
@@ -35,10 +36,8 @@ func (s *UserServiceTestSuite) SetupSuite() {
 		Conn: db,
 	}))
 	require.NoError(s.T(), err)
-	entities.AutoMigrate(s.DB)
-	require.NoError(s.T(), err)
 
-	s.service = services.NewUserService(s.DB)
+	s.service = services.NewUserService(repository.NewGormUserRepository(s.DB))
 }
 
 func (s *UserServiceTestSuite) TestServiceGetUser() {
@@ -48,12 +47,12 @@ func (s *UserServiceTestSuite) TestServiceGetUser() {
 		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).
 			AddRow("1", "utain"))
 
-	user, err := s.service.GetUser("1")
+	user, err := s.service.GetUser(context.Background(), "1")
 	s.Assert().NoError(err)
 	s.Assert().Contains(user.ID, "1")
 	s.Assert().Contains(user.Username, "utain")
 
-	userX, err := s.service.GetUser("x")
+	userX, err := s.service.GetUser(context.Background(), "x")
 	s.Assert().Error(err, "User id=x should not found")
 	s.Assert().Nil(userX)
 }
@@ -0,0 +1,211 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"go-example/internal/log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Config of the tls listener, tls is enabled when CertFile and KeyFile are set
+type Config struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile enable mutual tls, clients must present a certificate signed
+	// by one of the ca in this file
+	ClientCAFile string
+	// MinVersion available(1.0; 1.1; 1.2; 1.3), default 1.2, or 1.3 with the
+	// modern cipher policy
+	MinVersion string
+	// CipherPolicy available(default; intermediate; modern), modern requires
+	// tls 1.3
+	CipherPolicy string
+	// ReloadInterval how often the files are checked for changes
+	ReloadInterval time.Duration
+}
+
+// Enabled report whether tls is configured
+func (c Config) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+var (
+	ErrUndefinedTLSVersion   = fmt.Errorf("undefined tls version, available(1.0; 1.1; 1.2; 1.3)")
+	ErrUndefinedCipherPolicy = fmt.Errorf("undefined cipher policy, available(default; intermediate; modern)")
+	// ErrConflictingTLSVersion min version below 1.3 with the modern policy
+	ErrConflictingTLSVersion = fmt.Errorf("the modern cipher policy requires tls 1.3, unset the min version or set it to 1.3")
+)
+
+var versions = map[string]uint16{
+	"":    tls.VersionTLS12,
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// intermediateCipherSuites forward secret aead suites for tls 1.2, tls 1.3
+// suites are not configurable
+var intermediateCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// New build a server tls config, certificate and client ca are reloaded from
+// disk when the files change
+func New(cnf Config) (*tls.Config, error) {
+	minVersion, ok := versions[cnf.MinVersion]
+	if !ok {
+		return nil, ErrUndefinedTLSVersion
+	}
+	cfg := &tls.Config{MinVersion: minVersion}
+
+	switch cnf.CipherPolicy {
+	case "", "default":
+	case "intermediate":
+		cfg.CipherSuites = intermediateCipherSuites
+	case "modern":
+		if cnf.MinVersion != "" && minVersion != tls.VersionTLS13 {
+			return nil, ErrConflictingTLSVersion
+		}
+		cfg.MinVersion = tls.VersionTLS13
+	default:
+		return nil, ErrUndefinedCipherPolicy
+	}
+
+	r := &reloader{cnf: cnf}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	cfg.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return r.certificate(), nil
+	}
+	if cnf.ClientCAFile != "" {
+		// every handshake use the latest loaded ca, to verify the client and
+		// to hint it in the certificate request
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		cfg.ClientCAs = r.clientCAs
+		// http.Server add its protocols to a clone of cfg, not to the
+		// config returned for the handshake
+		cfg.NextProtos = []string{"h2", "http/1.1"}
+		r.base = cfg
+		cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.clientConfig(), nil
+		}
+	}
+	return cfg, nil
+}
+
+type reloader struct {
+	cnf Config
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	modTimes  map[string]time.Time
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	// base config cloned with the current clientCAs into client, the clone
+	// is kept so session tickets stay valid until the ca changes
+	base   *tls.Config
+	client *tls.Config
+}
+
+// certificate return the current certificate, reloading it first when the
+// reload interval elapsed and the files changed
+func (r *reloader) certificate() *tls.Certificate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reloadIfChanged()
+	return r.cert
+}
+
+// clientConfig return the config of a mutual tls handshake, reloading the
+// files first when the reload interval elapsed
+func (r *reloader) clientConfig() *tls.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reloadIfChanged()
+	if r.client == nil || r.client.ClientCAs != r.clientCAs {
+		r.client = r.base.Clone()
+		r.client.GetConfigForClient = nil
+		r.client.ClientCAs = r.clientCAs
+	}
+	return r.client
+}
+
+// reloadIfChanged must be called with r.mu held, a failed reload keeps the
+// previous certificate
+func (r *reloader) reloadIfChanged() {
+	interval := r.cnf.ReloadInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	if time.Since(r.checkedAt) < interval {
+		return
+	}
+	r.checkedAt = time.Now()
+	modTimes, err := r.stat()
+	if err != nil {
+		log.Error("failed to check tls files: " + err.Error())
+		return
+	}
+	for name, modTime := range modTimes {
+		if !modTime.Equal(r.modTimes[name]) {
+			if err := r.load(); err != nil {
+				log.Error("failed to reload tls files: " + err.Error())
+				return
+			}
+			log.Info("Reloaded tls certificate")
+			return
+		}
+	}
+}
+
+func (r *reloader) stat() (map[string]time.Time, error) {
+	modTimes := map[string]time.Time{}
+	for _, name := range []string{r.cnf.CertFile, r.cnf.KeyFile, r.cnf.ClientCAFile} {
+		if name == "" {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		modTimes[name] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+func (r *reloader) load() error {
+	modTimes, err := r.stat()
+	if err != nil {
+		return fmt.Errorf("failed to read tls files: %w", err)
+	}
+	cert, err := tls.LoadX509KeyPair(r.cnf.CertFile, r.cnf.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load tls certificate: %w", err)
+	}
+	var clientCAs *x509.CertPool
+	if r.cnf.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cnf.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client ca: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificate found in client ca file %s", r.cnf.ClientCAFile)
+		}
+	}
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.modTimes = modTimes
+	r.checkedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,200 @@
+package tlsconfig_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"go-example/internal/tlsconfig"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type TLSConfigTestSuite struct {
+	suite.Suite
+	dir    string
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	client tls.Certificate
+	cnf    tlsconfig.Config
+	writes int
+}
+
+func (s *TLSConfigTestSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+	s.ca, s.caKey = s.issue("test-ca", nil, nil, x509.ExtKeyUsageAny)
+	s.writePEM("ca.crt", "CERTIFICATE", s.ca.Raw)
+
+	s.writeServerCert("server-1")
+	client, clientKey := s.issue("client", s.ca, s.caKey, x509.ExtKeyUsageClientAuth)
+	s.client = tls.Certificate{Certificate: [][]byte{client.Raw}, PrivateKey: clientKey}
+
+	s.cnf = tlsconfig.Config{
+		CertFile:       filepath.Join(s.dir, "tls.crt"),
+		KeyFile:        filepath.Join(s.dir, "tls.key"),
+		ClientCAFile:   filepath.Join(s.dir, "ca.crt"),
+		ReloadInterval: time.Nanosecond,
+	}
+}
+
+func (s *TLSConfigTestSuite) issue(cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, usage x509.ExtKeyUsage) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(s.T(), err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{"127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	require.NoError(s.T(), err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(s.T(), err)
+	return cert, key
+}
+
+func (s *TLSConfigTestSuite) writePEM(name, blockType string, der []byte) {
+	path := filepath.Join(s.dir, name)
+	require.NoError(s.T(), os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+	// make sure the modification time changes between writes
+	s.writes++
+	modTime := time.Now().Add(time.Duration(s.writes) * time.Second)
+	require.NoError(s.T(), os.Chtimes(path, modTime, modTime))
+}
+
+func (s *TLSConfigTestSuite) writeServerCert(cn string) {
+	cert, key := s.issue(cn, s.ca, s.caKey, x509.ExtKeyUsageServerAuth)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(s.T(), err)
+	s.writePEM("tls.crt", "CERTIFICATE", cert.Raw)
+	s.writePEM("tls.key", "EC PRIVATE KEY", keyDER)
+}
+
+func (s *TLSConfigTestSuite) serve() net.Listener {
+	cfg, err := tlsconfig.New(s.cnf)
+	require.NoError(s.T(), err)
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	require.NoError(s.T(), err)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})}
+	go srv.Serve(ln)
+	s.T().Cleanup(func() { srv.Close() })
+	return ln
+}
+
+func (s *TLSConfigTestSuite) dial(ln net.Listener, certs ...tls.Certificate) (*tls.Conn, error) {
+	return tls.Dial("tcp", ln.Addr().String(), &tls.Config{
+		InsecureSkipVerify: true,
+		Certificates:       certs,
+	})
+}
+
+func (s *TLSConfigTestSuite) TestMutualTLS() {
+	srv := s.serve()
+
+	conn, err := tls.Dial("tcp", srv.Addr().String(), &tls.Config{
+		InsecureSkipVerify: true,
+		Certificates:       []tls.Certificate{s.client},
+		NextProtos:         []string{"h2"},
+	})
+	s.Require().NoError(err)
+	s.NoError(conn.Handshake())
+	s.Equal("h2", conn.ConnectionState().NegotiatedProtocol, "http/2 must stay available")
+	conn.Close()
+
+	conn, err = s.dial(srv)
+	if err == nil {
+		// tls 1.3 report the client certificate failure on first read
+		_, err = conn.Read(make([]byte, 1))
+		conn.Close()
+	}
+	s.Error(err, "client without certificate must be rejected")
+}
+
+func (s *TLSConfigTestSuite) TestReloadCertificate() {
+	srv := s.serve()
+
+	conn, err := s.dial(srv, s.client)
+	s.Require().NoError(err)
+	s.Equal("server-1", conn.ConnectionState().PeerCertificates[0].Subject.CommonName)
+	conn.Close()
+
+	s.writeServerCert("server-2")
+	conn, err = s.dial(srv, s.client)
+	s.Require().NoError(err)
+	s.Equal("server-2", conn.ConnectionState().PeerCertificates[0].Subject.CommonName)
+	conn.Close()
+}
+
+func (s *TLSConfigTestSuite) TestReloadClientCA() {
+	srv := s.serve()
+	var hints [][]byte
+	dial := func() error {
+		conn, err := tls.Dial("tcp", srv.Addr().String(), &tls.Config{
+			InsecureSkipVerify: true,
+			GetClientCertificate: func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				hints = info.AcceptableCAs
+				return &s.client, nil
+			},
+		})
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		// tls 1.3 report the client certificate failure on first read
+		conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		if _, err := conn.Read(make([]byte, 1)); err != nil && !os.IsTimeout(err) {
+			return err
+		}
+		return nil
+	}
+	s.Require().NoError(dial())
+	s.Equal([][]byte{s.ca.RawSubject}, hints, "the client ca must be hinted")
+
+	other, _ := s.issue("other-ca", nil, nil, x509.ExtKeyUsageAny)
+	s.writePEM("ca.crt", "CERTIFICATE", other.Raw)
+	s.Error(dial(), "clients of the replaced ca must be rejected")
+	s.Equal([][]byte{other.RawSubject}, hints)
+}
+
+func (s *TLSConfigTestSuite) TestInvalidSettings() {
+	s.cnf.MinVersion = "2.0"
+	_, err := tlsconfig.New(s.cnf)
+	s.ErrorIs(err, tlsconfig.ErrUndefinedTLSVersion)
+
+	s.cnf.MinVersion = ""
+	s.cnf.CipherPolicy = "weak"
+	_, err = tlsconfig.New(s.cnf)
+	s.ErrorIs(err, tlsconfig.ErrUndefinedCipherPolicy)
+
+	s.cnf.MinVersion = "1.2"
+	s.cnf.CipherPolicy = "modern"
+	_, err = tlsconfig.New(s.cnf)
+	s.ErrorIs(err, tlsconfig.ErrConflictingTLSVersion)
+
+	s.cnf.MinVersion = "1.3"
+	_, err = tlsconfig.New(s.cnf)
+	s.NoError(err)
+}
+
+func TestTLSConfigTestSuite(t *testing.T) {
+	suite.Run(t, new(TLSConfigTestSuite))
+}
@@ -0,0 +1,21 @@
+package trace
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// InitInMemoryProvider set a global provider which export every ended span
+// synchronously to the returned exporter, so tests can assert on the spans
+// emitted by the code under test
+func InitInMemoryProvider() *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSyncer(exporter),
+	))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return exporter
+}
@@ -0,0 +1,105 @@
+package trace
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+
+	"go-example/internal/route"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/semconv/v1.17.0/httpconv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceResponseHeader W3C trace context response header, it tells the client
+// which trace and span served its request
+const TraceResponseHeader = "traceresponse"
+
+// instrumentationName name of the tracer of the http middleware
+const instrumentationName = "go-example/internal/trace"
+
+// Middleware start a server span for every request, continuing the trace of
+// the traceparent header. Spans are named by method and route pattern so
+// requests to the same route are grouped, the route is resolved by chi or by
+// route.Set from a mounted router
+func Middleware() func(http.Handler) http.Handler {
+	tracer := GetTracer(instrumentationName)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, "HTTP "+r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(httpconv.ServerRequest("", r)...))
+			defer span.End()
+
+			rw := &responseWriter{ResponseWriter: w, span: span}
+			r = route.Track(r.WithContext(ctx))
+			next.ServeHTTP(rw, r)
+
+			if pattern := route.Pattern(r); pattern != "" {
+				span.SetName(r.Method + " " + pattern)
+				span.SetAttributes(semconv.HTTPRoute(pattern))
+			}
+			status := rw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			span.SetAttributes(semconv.HTTPStatusCode(status))
+			span.SetStatus(httpconv.ServerStatus(status))
+		})
+	}
+}
+
+// responseWriter remember the status and add the traceresponse header before
+// the response header is sent
+type responseWriter struct {
+	http.ResponseWriter
+	span   trace.Span
+	status int
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		// a span which is not recorded is unknown to the backend
+		if sc := w.span.SpanContext(); w.span.IsRecording() && sc.IsValid() {
+			w.Header().Set(TraceResponseHeader,
+				fmt.Sprintf("00-%s-%s-%s", sc.TraceID(), sc.SpanID(), sc.TraceFlags()))
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush support streaming responses
+func (w *responseWriter) Flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack support websocket upgrades
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, fmt.Errorf("hijack is not supported by %T", w.ResponseWriter)
+}
+
+// Unwrap expose the wrapped writer to http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,82 @@
+package trace_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-example/internal/route"
+	"go-example/internal/trace"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	traceparent = "00-" + traceID + "-00f067aa0ba902b7-01"
+)
+
+func attrs(kvs []attribute.KeyValue) map[attribute.Key]attribute.Value {
+	m := map[attribute.Key]attribute.Value{}
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	api := gin.New()
+	api.Use(route.GinPattern())
+	api.GET("/api/items/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	api.GET("/api/fail", func(c *gin.Context) { c.Status(http.StatusBadGateway) })
+
+	r := chi.NewRouter()
+	r.Use(trace.Middleware())
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) })
+	r.Mount("/api", api)
+
+	serve := func(url string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", url, nil)
+		req.Header.Set("traceparent", traceparent)
+		req.Header.Set("User-Agent", "test-agent")
+		r.ServeHTTP(res, req)
+		return res
+	}
+
+	res := serve("/api/items/42")
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	require.Equal(t, "GET /api/items/:id", span.Name())
+	require.Equal(t, traceID, span.SpanContext().TraceID().String(), "upstream trace must continue")
+	require.True(t, span.Parent().IsRemote())
+	a := attrs(span.Attributes())
+	require.Equal(t, "GET", a["http.method"].AsString())
+	require.Equal(t, "/api/items/:id", a["http.route"].AsString())
+	require.Equal(t, int64(200), a["http.status_code"].AsInt64())
+	require.Equal(t, "test-agent", a["http.user_agent"].AsString())
+	require.Equal(t, "00-"+traceID+"-"+span.SpanContext().SpanID().String()+"-01", res.Header().Get(trace.TraceResponseHeader))
+
+	serve("/users/7")
+	require.Equal(t, "GET /users/{id}", recorder.Ended()[1].Name())
+
+	serve("/api/fail")
+	span = recorder.Ended()[2]
+	require.Equal(t, codes.Error, span.Status().Code, "5xx must mark the span as failed")
+
+	serve("/unknown")
+	require.Equal(t, "HTTP GET", recorder.Ended()[3].Name(), "raw paths must not name spans")
+}
@@ -51,7 +51,7 @@ func (s *Spaner) SetName(name string) {
 	if s == nil {
 		return
 	}
-	s.SetName(name)
+	s.s.SetName(name)
 }
 func (s *Spaner) SetAttributes(kv ...attribute.KeyValue) {
 	if s == nil {
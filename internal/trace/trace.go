@@ -2,13 +2,16 @@ package trace
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"time"
 
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
@@ -21,12 +24,12 @@ import (
 type Config struct {
 	Proto    string
 	Endpoint string
+	// Output file of the stdout protocol, stderr when empty
+	Output string
 }
 
 var (
-	tracer Tracer
-
-	ErrUndefindedTraceProto = fmt.Errorf("undefined trace protocol, available(http; grpc)")
+	ErrUndefindedTraceProto = fmt.Errorf("undefined trace protocol, available(http; grpc; stdout; none)")
 )
 
 type Tracer struct {
@@ -50,7 +53,8 @@ func InitTraceProvider(ctx context.Context, serviceName, serviceVersion string,
 	ctx, cancel := context.WithTimeout(ctx, time.Second)
 	defer cancel()
 
-	var traceExporter *otlptrace.Exporter
+	var traceExporter sdktrace.SpanExporter
+	closeOutput := func() error { return nil }
 
 	switch cnf.Proto {
 	case "http":
@@ -72,6 +76,21 @@ func InitTraceProvider(ctx context.Context, serviceName, serviceVersion string,
 		if traceExporter, err = otlptracegrpc.New(ctx, otlptracegrpc.WithGRPCConn(conn)); err != nil {
 			return nil, fmt.Errorf("failed to create trace exporter: %w", err)
 		}
+	case "stdout":
+		var w io.Writer
+		if w, closeOutput, err = openOutput(cnf.Output); err != nil {
+			return nil, err
+		}
+		if traceExporter, err = stdouttrace.New(stdouttrace.WithWriter(w), stdouttrace.WithPrettyPrint()); err != nil {
+			closeOutput()
+			return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+		}
+	case "none":
+		// spans are not recorded, the trace context of requests is still
+		// propagated to downstream services
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		return func(context.Context) error { return nil }, nil
 	default:
 		return nil, ErrUndefindedTraceProto
 	}
@@ -90,17 +109,34 @@ func InitTraceProvider(ctx context.Context, serviceName, serviceVersion string,
 	otel.SetTextMapPropagator(propagation.TraceContext{})
 
 	// Shutdown will flush any remaining spans and shut down the exporter.
-	return tracerProvider.Shutdown, nil
+	return func(ctx context.Context) error {
+		return errors.Join(tracerProvider.Shutdown(ctx), closeOutput())
+	}, nil
+}
+
+// openOutput open the file of the stdout exporter, stderr when path is empty
+func openOutput(path string) (io.Writer, func() error, error) {
+	if path == "" {
+		return os.Stderr, func() error { return nil }, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open trace output: %w", err)
+	}
+	return f, f.Close, nil
 }
 
+// GetTracer return the tracer of the instrumentation name from the global
+// provider, spans started before the provider is set are delegated to it
 func GetTracer(name string, opts ...trace.TracerOption) *Tracer {
-	tracer.t = otel.Tracer(name, opts...)
-	return &tracer
+	return &Tracer{t: otel.Tracer(name, opts...)}
 }
 
+// Start a span, a zero Tracer start non-recording spans so callers never get
+// a nil span
 func (t *Tracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
-	if t.t == nil {
-		return ctx, nil
+	if t == nil || t.t == nil {
+		return trace.NewNoopTracerProvider().Tracer("").Start(ctx, spanName, opts...)
 	}
 	ctx, span := t.t.Start(ctx, spanName, opts...)
 	return ctx, &Spaner{s: span}
@@ -0,0 +1,57 @@
+package trace_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-example/internal/trace"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestZeroTracerStart(t *testing.T) {
+	var tracer trace.Tracer
+	_, span := tracer.Start(context.Background(), "work")
+	require.NotNil(t, span)
+	require.False(t, span.IsRecording())
+	span.End()
+}
+
+func TestInitTraceProvider(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err := trace.InitTraceProvider(context.Background(), "server", "test", trace.Config{Proto: "stdout", Output: output})
+	require.NoError(t, err)
+	_, span := trace.GetTracer("test").Start(context.Background(), "stdout-work")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+	b, err := os.ReadFile(output)
+	require.NoError(t, err)
+	require.Contains(t, string(b), `"Name": "stdout-work"`)
+	require.Contains(t, string(b), `"Value": "server"`)
+
+	shutdown, err = trace.InitTraceProvider(context.Background(), "server", "test", trace.Config{Proto: "none"})
+	require.NoError(t, err)
+	_, span = trace.GetTracer("test").Start(context.Background(), "dropped")
+	require.False(t, span.IsRecording())
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	_, err = trace.InitTraceProvider(context.Background(), "server", "test", trace.Config{})
+	require.ErrorIs(t, err, trace.ErrUndefindedTraceProto)
+}
+
+func TestInitInMemoryProvider(t *testing.T) {
+	exporter := trace.InitInMemoryProvider()
+	ctx, parent := trace.GetTracer("test").Start(context.Background(), "parent")
+	_, child := otel.Tracer("test").Start(ctx, "child")
+	child.End()
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	require.Equal(t, "child", spans[0].Name)
+	require.Equal(t, spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID())
+}